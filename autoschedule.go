@@ -0,0 +1,116 @@
+/** ****************************************************************************************************************** **
+	Auto scheduling leads
+	Picks the first open slot for a lead based on the team's current schedules, and books it
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "github.com/pkg/errors"
+
+    "context"
+    "time"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// how far out we look when the caller doesn't give us an end
+const autoScheduleDays = 14
+
+var ErrNoAvailability = errors.New("No open slots")
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// anything left empty in the slot request is filled in from the lead
+// the range starts now and goes 2 weeks out, the duration is the lead's current length
+// and the timezone is the lead's
+type ScheduleConstraints struct {
+    SlotRequest
+    Team Members // who to consider, pulled with ListTeam when empty
+    Preview bool // work out where it would go, without changing anything in workiz
+}
+
+// where a lead ended up, or would end up in a preview
+type Placement struct {
+    LeadId string
+    Member *Member
+    Start, End time.Time // the account's wall clock time, same as the lead's times once it's booked
+    Applied bool // false for a preview
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// the slot request with anything the caller left empty filled in from the lead
+func (this ScheduleConstraints) slotRequest (lead *Lead, now time.Time) SlotRequest {
+    req := this.SlotRequest
+    if req.Start.IsZero() { req.Start = now }
+    if req.Location == nil && len(lead.Timezone) > 0 {
+        if loc, err := time.LoadLocation (lead.Timezone); err == nil { req.Location = loc } // otherwise Start's location it is
+    }
+    if req.End.IsZero() { req.End = req.Start.AddDate (0, 0, autoScheduleDays) }
+    if len(req.JobType) == 0 { req.JobType = firstNonEmpty (lead.JobType, lead.LeadType) }
+    if len(req.ServiceArea) == 0 { req.ServiceArea = lead.ServiceArea }
+    if req.Duration <= 0 {
+        start, end := lead.Window()
+        req.Duration = end.Sub (start)
+    }
+    return req
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// finds the first open slot for the lead, then schedules it and assigns the tech
+// returns ErrNoAvailability if nobody has room in the range
+func (this *Workiz) AutoScheduleLead (ctx context.Context, token, secret, leadId string, constraints ScheduleConstraints) (*Placement, error) {
+    lead, err := this.GetLead (ctx, token, leadId)
+    if err != nil { return nil, err }
+
+    req := constraints.slotRequest (lead, time.Now())
+
+    team := constraints.Team
+    if len(team) == 0 {
+        team, err = this.ListTeam (ctx, token)
+        if err != nil { return nil, err }
+    }
+
+    // everything that's already booked in the range, a few records we can't read shouldn't stop us
+    // workiz has them on the account's clock, so that's what we ask for
+    start, end := req.wallClockRange()
+    jobs, _, err := this.ListJobsPartial (ctx, token, start, end)
+    if err != nil { return nil, err }
+
+    leads, _, err := this.ListLeadsPartial (ctx, token, start, end)
+    if err != nil { return nil, err }
+
+    others := make([]*Lead, 0, len(leads))
+    for _, l := range leads {
+        if l.UUID != leadId { others = append (others, l) } // it's getting moved, so it doesn't count
+    }
+
+    slots := FindOpenSlots (team, jobs, others, req)
+    if len(slots) == 0 {
+        return nil, errors.Wrapf (ErrNoAvailability, "%s : %s - %s", leadId, start, end)
+    }
+
+    ret := &Placement { LeadId: leadId, Member: slots[0].Member, Start: slots[0].Start, End: slots[0].End }
+    if constraints.Preview { return ret, nil }
+
+    // the slot is on the account's clock, and UpdateLeadSchedule tells workiz it's getting utc
+    err = this.UpdateLeadSchedule (ctx, token, secret, leadId, fromWallClock (ret.Start, req.location()).UTC(), req.Duration)
+    if err != nil { return nil, err }
+
+    err = this.UpdateLeadCrew (ctx, token, secret, leadId, team, []string{ ret.Member.Name })
+    if err != nil { return ret, err } // it's scheduled, so let them know where even though the crew didn't work
+
+    ret.Applied = true
+    return ret, nil
+}
@@ -0,0 +1,68 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAutoScheduleRequest (t *testing.T) {
+	lead := &Lead{}
+	err := json.Unmarshal ([]byte(`{"UUID":"ABC123","LeadDateTime":"2023-02-28 09:00:00","LeadEndDateTime":"2023-02-28 11:00:00",
+		"JobType":"Growler Fill","ServiceArea":"Burlington","Team":[]}`), lead)
+	if err != nil { t.Fatal (err) }
+
+	now := time.Date (2023, 2, 27, 12, 0, 0, 0, time.UTC)
+
+	// everything comes from the lead
+	req := ScheduleConstraints{}.slotRequest (lead, now)
+	assert.Equal (t, now, req.Start)
+	assert.Equal (t, now.AddDate (0, 0, autoScheduleDays), req.End)
+	assert.Equal (t, 2 * time.Hour, req.Duration)
+	assert.Equal (t, "Growler Fill", req.JobType)
+	assert.Equal (t, "Burlington", req.ServiceArea)
+
+	// what the caller sets wins
+	c := ScheduleConstraints { SlotRequest: SlotRequest { Duration: time.Hour, ServiceArea: "Essex" } }
+	req = c.slotRequest (lead, now)
+	assert.Equal (t, time.Hour, req.Duration)
+	assert.Equal (t, "Essex", req.ServiceArea)
+
+	// and it lines up with the open slots, 1 is the only one with the skill
+	req.ServiceArea = "Burlington"
+	slots := FindOpenSlots (testMembers(), nil, nil, req)
+	if assert.Equal (t, true, len(slots) > 0) {
+		assert.Equal (t, "1", slots[0].Member.Id)
+	}
+}
+
+func TestAutoScheduleTimezone (t *testing.T) {
+	lead := &Lead{}
+	err := json.Unmarshal ([]byte(`{"UUID":"ABC123","LeadDateTime":"2023-02-28 09:00:00","LeadEndDateTime":"2023-02-28 11:00:00",
+		"Timezone":"America/New_York","Team":[]}`), lead)
+	if err != nil { t.Fatal (err) }
+
+	// 5pm utc on a monday is noon in new york
+	now := time.Date (2023, 2, 27, 17, 0, 0, 0, time.UTC)
+	req := ScheduleConstraints{}.slotRequest (lead, now)
+	if assert.NotNil (t, req.Location) {
+		assert.Equal (t, "America/New_York", req.Location.String())
+	}
+
+	// someone is already booked from noon to 2 there
+	jobs := []*Job { testJob (t, "AAA111", "2023-02-27 12:00", 120, 44.3998, -73.2037, "1") }
+
+	start, _ := req.wallClockRange()
+	assert.Equal (t, time.Date (2023, 2, 27, 12, 0, 0, 0, time.UTC), start)
+
+	slots := FindOpenSlots (testMembers(), jobs, nil, req)
+	if assert.Equal (t, true, len(slots) > 0) {
+		assert.Equal (t, time.Date (2023, 2, 27, 12, 0, 0, 0, time.UTC), slots[0].Start) // noon on their clock
+		assert.NotEqual (t, "1", slots[0].Member.Id)
+
+		// and it goes to workiz as the real time
+		assert.Equal (t, now, fromWallClock (slots[0].Start, req.location()).UTC())
+	}
+}
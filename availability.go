@@ -0,0 +1,175 @@
+/** ****************************************************************************************************************** **
+	Availability
+	Finds open time in the techs' schedules, so we can offer real times when someone books
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "sort"
+    "time"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+const defaultSlotStep = 30 * time.Minute
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// when the business takes appointments, the zero value is 8 to 5 monday to friday
+type BusinessHours struct {
+    Open, Close time.Duration // time since midnight
+    Days []time.Weekday
+}
+
+func (this BusinessHours) open (day time.Weekday) bool {
+    if len(this.Days) == 0 { return day != time.Saturday && day != time.Sunday }
+
+    for _, d := range this.Days {
+        if d == day { return true }
+    }
+    return false
+}
+
+func (this BusinessHours) hours () (time.Duration, time.Duration) {
+    if this.Open == 0 && this.Close == 0 { return 8 * time.Hour, 17 * time.Hour }
+    return this.Open, this.Close
+}
+
+// what we're looking for
+type SlotRequest struct {
+    Start, End time.Time // the range to look in
+    Duration time.Duration // how long the appointment is
+    Hours BusinessHours
+    Location *time.Location // the account's timezone, Start and End are moved into it. defaults to Start's location
+    JobType, ServiceArea string // when set, the tech needs this skill and service area
+    Buffer time.Duration // time to leave open before and after the tech's other appointments
+    Step time.Duration // slots start on these boundaries from opening, 30 minutes if not set
+    PerTech int // most slots to return for each tech, 0 for all of them
+}
+
+type Slot struct {
+    Member *Member
+    Start, End time.Time // the account's wall clock time, labelled utc like the times we get from workiz
+    JobsThatDay int // how much they already have going on, fewer is better
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// true if the member can do the kind of work being asked for
+func (this SlotRequest) eligible (m *Member) bool {
+    if m.Active == false || m.FieldTech == false { return false }
+    if len(this.JobType) > 0 && m.HasSkill (this.JobType) == false { return false }
+    if len(this.ServiceArea) > 0 && m.Covers (this.ServiceArea) == false { return false }
+    return true
+}
+
+// the same time on the account's clock, labelled utc the way workiz times are
+// so 2pm utc for an account in new york comes back as 9am "utc"
+func wallClock (t time.Time, loc *time.Location) time.Time {
+    y, mo, d := t.In (loc).Date()
+    h, min, sec := t.In (loc).Clock()
+    return time.Date (y, mo, d, h, min, sec, t.Nanosecond(), time.UTC)
+}
+
+// back from the account's wall clock to the real time, for sending to calls that take utc
+func fromWallClock (t time.Time, loc *time.Location) time.Time {
+    y, mo, d := t.Date()
+    h, min, sec := t.Clock()
+    return time.Date (y, mo, d, h, min, sec, t.Nanosecond(), loc)
+}
+
+// the time of day on the day, by the clock rather than adding hours, so it's right when the clocks change
+func atClock (day time.Time, since time.Duration) time.Time {
+    y, mo, d := day.Date()
+    return time.Date (y, mo, d, int(since / time.Hour), int(since % time.Hour / time.Minute), 0, 0, day.Location())
+}
+
+// the range we're looking in, as the account's wall clock time
+func (this SlotRequest) wallClockRange () (time.Time, time.Time) {
+    return wallClock (this.Start, this.location()), wallClock (this.End, this.location())
+}
+
+func (this SlotRequest) location () *time.Location {
+    if this.Location == nil { return this.Start.Location() }
+    return this.Location
+}
+
+// open slots for one tech, in time order
+// everything in here is wall clock time, the appointments from workiz already are and the range gets moved to match
+func (this SlotRequest) memberSlots (m *Member, appts []*appointment) (ret []*Slot) {
+    start, end := this.wallClockRange()
+
+    step := this.Step
+    if step <= 0 { step = defaultSlotStep }
+
+    openAt, closeAt := this.Hours.hours()
+    mine := memberAppointments (m, appts)
+
+    y, mo, d := start.Date()
+    for day := time.Date (y, mo, d, 0, 0, 0, 0, time.UTC); day.Before (end); day = day.AddDate (0, 0, 1) {
+        if this.Hours.open (day.Weekday()) == false { continue }
+
+        dayOpen, dayClose := atClock (day, openAt), atClock (day, closeAt)
+
+        // what's already booked that day, with the buffer around it
+        var busy [][2]time.Time
+        for _, a := range mine {
+            if a.end.After (dayOpen) && a.start.Before (dayClose) {
+                busy = append (busy, [2]time.Time { a.start.Add (-this.Buffer), a.end.Add (this.Buffer) })
+            }
+        }
+
+        for t := dayOpen; t.Add (this.Duration).After (dayClose) == false; t = t.Add (step) {
+            slotEnd := t.Add (this.Duration)
+            if t.Before (start) || slotEnd.After (end) { continue }
+
+            free := true
+            for _, b := range busy {
+                if t.Before (b[1]) && b[0].Before (slotEnd) {
+                    free = false
+                    break
+                }
+            }
+            if free == false { continue }
+
+            ret = append (ret, &Slot { Member: m, Start: t, End: slotEnd, JobsThatDay: len(busy) })
+            if this.PerTech > 0 && len(ret) >= this.PerTech { return }
+        }
+    }
+    return
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// finds open slots across the techs who can do the work
+// jobs and leads should cover the whole range, with their crews, so we know who's busy when
+// the slots are ranked soonest first, then by whoever has the lighter day
+func FindOpenSlots (members Members, jobs []*Job, leads []*Lead, req SlotRequest) []*Slot {
+    appts := toAppointments (jobs, leads)
+
+    ret := make([]*Slot, 0)
+    if req.Duration <= 0 { return ret } // nothing to fit
+
+    for _, m := range members {
+        if req.eligible (m) {
+            ret = append (ret, req.memberSlots (m, appts)...)
+        }
+    }
+
+    sort.SliceStable (ret, func (i, j int) bool {
+        if ret[i].Start.Equal (ret[j].Start) == false { return ret[i].Start.Before (ret[j].Start) }
+        if ret[i].JobsThatDay != ret[j].JobsThatDay { return ret[i].JobsThatDay < ret[j].JobsThatDay }
+        return ret[i].Member.Name < ret[j].Member.Name
+    })
+    return ret
+}
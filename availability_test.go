@@ -0,0 +1,92 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"testing"
+	"time"
+)
+
+func TestFindOpenSlots (t *testing.T) {
+	jobs := []*Job {
+		testJob (t, "AAA111", "2023-02-28 08:00", 240, 44.3998, -73.2037, "1"), // 8 to 12
+		testJob (t, "BBB222", "2023-02-28 13:00", 240, 44.3998, -73.2037, "1"), // 1 to 5
+		testJob (t, "CCC333", "2023-02-28 08:00", 120, 44.3998, -73.2037, "3"), // 8 to 10
+	}
+
+	req := SlotRequest {
+		Start: time.Date (2023, 2, 28, 0, 0, 0, 0, time.UTC), // a tuesday
+		End: time.Date (2023, 3, 1, 0, 0, 0, 0, time.UTC),
+		Duration: 2 * time.Hour,
+		JobType: "Growler Fill",
+		Buffer: 30 * time.Minute,
+	}
+
+	// 1 is the only one with the skill, and they're booked all day
+	assert.Equal (t, 0, len(FindOpenSlots (testMembers(), jobs, nil, req)))
+
+	// without the skill, 2 is free right at opening and 3 after their job and the buffer
+	// 4 is office staff so they never show up
+	req.JobType = ""
+	slots := FindOpenSlots (testMembers(), jobs, nil, req)
+	if assert.Equal (t, true, len(slots) > 2) {
+		assert.Equal (t, "2", slots[0].Member.Id)
+		assert.Equal (t, 8, slots[0].Start.Hour())
+		assert.Equal (t, 0, slots[0].JobsThatDay)
+
+		last := slots[len(slots)-1]
+		assert.Equal (t, 17, last.End.Hour())
+	}
+
+	req.PerTech = 1
+	slots = FindOpenSlots (testMembers(), jobs, nil, req)
+	assert.Equal (t, 2, len(slots))
+	assert.Equal (t, "3", slots[1].Member.Id)
+	assert.Equal (t, 10, slots[1].Start.Hour())
+	assert.Equal (t, 30, slots[1].Start.Minute())
+	assert.Equal (t, 1, slots[1].JobsThatDay)
+
+	// saturday is closed by default
+	req.Start = time.Date (2023, 3, 4, 0, 0, 0, 0, time.UTC)
+	req.End = req.Start.AddDate (0, 0, 1)
+	assert.Equal (t, 0, len(FindOpenSlots (testMembers(), jobs, nil, req)))
+}
+
+func TestFindOpenSlotsTimezone (t *testing.T) {
+	ny, err := time.LoadLocation ("America/New_York")
+	if err != nil { t.Fatal (err) }
+
+	// workiz gives us new york wall clock times, so this is 8 to 10 in the morning there
+	jobs := []*Job {
+		testJob (t, "AAA111", "2023-02-28 08:00", 120, 44.3998, -73.2037, "2"),
+	}
+
+	// 1:30 in the afternoon utc is 8:30 in the morning in new york
+	req := SlotRequest {
+		Start: time.Date (2023, 2, 28, 13, 30, 0, 0, time.UTC),
+		End: time.Date (2023, 3, 1, 0, 0, 0, 0, time.UTC),
+		Duration: time.Hour,
+		Location: ny,
+		PerTech: 1,
+	}
+
+	slots := FindOpenSlots (testMembers(), jobs, nil, req)
+	if assert.Equal (t, 3, len(slots)) {
+		assert.Equal (t, time.Date (2023, 2, 28, 8, 30, 0, 0, time.UTC), slots[0].Start) // not 1:30, and not before the range starts
+		assert.NotEqual (t, "2", slots[0].Member.Id) // they're still on their job
+		assert.Equal (t, "2", slots[2].Member.Id)
+		assert.Equal (t, 10, slots[2].Start.Hour())
+	}
+
+	// the clocks go forward on the 12th, opening is still 8 and closing is still 5
+	req.Start = time.Date (2023, 3, 12, 0, 0, 0, 0, ny)
+	req.End = req.Start.AddDate (0, 0, 1)
+	req.Hours = BusinessHours { Open: 8 * time.Hour, Close: 17 * time.Hour, Days: []time.Weekday { time.Sunday } }
+	req.PerTech = 0
+
+	slots = FindOpenSlots (testMembers(), nil, nil, req)
+	if assert.Equal (t, true, len(slots) > 0) {
+		assert.Equal (t, time.Date (2023, 3, 12, 8, 0, 0, 0, time.UTC), slots[0].Start)
+		assert.Equal (t, time.Date (2023, 3, 12, 17, 0, 0, 0, time.UTC), slots[len(slots)-1].End)
+	}
+}
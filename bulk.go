@@ -0,0 +1,137 @@
+/** ****************************************************************************************************************** **
+	Bulk calls
+	Fans a list of ids out over a few workers so we're not waiting on 200 requests in a row
+	Every request still goes through send, so the rate limit and quota retries apply
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "github.com/pkg/errors"
+
+    "fmt"
+    "context"
+    "strings"
+    "sync"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// don't go crazy if the caller doesn't say
+const defaultConcurrency = 4
+
+var ErrPartialBatch = errors.New("Some items in the batch failed")
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// what went wrong for a single id in a batch
+type BatchError struct {
+    Id string
+    Err error
+}
+
+func (this *BatchError) Error () string {
+    return fmt.Sprintf ("%s : %s", this.Id, this.Err.Error())
+}
+
+// every failure in a batch, in the same order as the ids that went in
+// errors.Cause() on this is ErrPartialBatch, check errors.Cause() on each Err for the specific problem
+type BatchErrors []*BatchError
+
+func (this BatchErrors) Error () string {
+    msgs := make([]string, 0, len(this))
+    for _, e := range this { msgs = append (msgs, e.Error()) }
+    return fmt.Sprintf ("%s : %d : %s", ErrPartialBatch.Error(), len(this), strings.Join (msgs, " | "))
+}
+
+func (this BatchErrors) Cause () error { return ErrPartialBatch }
+func (this BatchErrors) Unwrap () error { return ErrPartialBatch }
+
+// returns the error for a specific id, nil if it worked
+func (this BatchErrors) For (id string) error {
+    for _, e := range this {
+        if e.Id == id { return e.Err }
+    }
+    return nil
+}
+
+func (this BatchErrors) err () error {
+    if len(this) == 0 { return nil }
+    return this
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// calls fn with every index from 0 to count-1, with at most concurrency of them running at once
+// once the context is done the rest are skipped, fn won't see them
+func runPool (ctx context.Context, count, concurrency int, fn func (int)) {
+    if concurrency <= 0 { concurrency = defaultConcurrency }
+    if concurrency > count { concurrency = count }
+
+    work := make(chan int)
+    wg := &sync.WaitGroup{}
+
+    for w := 0; w < concurrency; w++ {
+        wg.Add (1)
+        go func () {
+            defer wg.Done()
+            for i := range work { fn (i) }
+        }()
+    }
+
+    for i := 0; i < count; i++ {
+        if ctx.Err() != nil { break } // we're out of time, don't start anything new
+        work <- i
+    }
+    close (work)
+    wg.Wait()
+}
+
+// runs fn for each id using a pool of workers
+// results line up with the ids, anything that failed is left as the zero value and reported in the errors
+func fanOut[T any] (ctx context.Context, ids []string, concurrency int, fn func (context.Context, string) (T, error)) ([]T, BatchErrors) {
+    ret := make([]T, len(ids))
+    errs := make([]error, len(ids))
+    started := make([]bool, len(ids))
+
+    runPool (ctx, len(ids), concurrency, func (i int) {
+        started[i] = true
+        ret[i], errs[i] = fn (ctx, ids[i])
+    })
+
+    var bad BatchErrors
+    for i, err := range errs {
+        if started[i] == false { err = ctx.Err() } // skipped because we ran out of time
+        if err != nil { bad = append (bad, &BatchError { Id: ids[i], Err: err }) }
+    }
+    return ret, bad
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// gets a bunch of jobs at once, concurrency is how many requests can be in flight
+// the jobs come back in the same order as the ids, with a nil for any that failed
+// failures are returned as BatchErrors, so one missing job doesn't cost you the rest
+func (this *Workiz) GetJobs (ctx context.Context, token string, jobIds []string, concurrency int) ([]*Job, error) {
+    jobs, bad := fanOut (ctx, jobIds, concurrency, func (ctx context.Context, id string) (*Job, error) {
+        return this.GetJob (ctx, token, id)
+    })
+    return jobs, bad.err()
+}
+
+// same as GetJobs, but for leads
+func (this *Workiz) GetLeads (ctx context.Context, token string, leadIds []string, concurrency int) ([]*Lead, error) {
+    leads, bad := fanOut (ctx, leadIds, concurrency, func (ctx context.Context, id string) (*Lead, error) {
+        return this.GetLead (ctx, token, id)
+    })
+    return leads, bad.err()
+}
@@ -0,0 +1,54 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/pkg/errors"
+
+	"testing"
+	"context"
+	"time"
+	"sync/atomic"
+)
+
+func TestFanOut (t *testing.T) {
+	ids := []string{"a", "b", "missing", "d", "e", "f"}
+
+	var running, most int32
+	results, bad := fanOut (context.Background(), ids, 2, func (ctx context.Context, id string) (string, error) {
+		now := atomic.AddInt32 (&running, 1)
+		defer atomic.AddInt32 (&running, -1)
+
+		for {
+			old := atomic.LoadInt32 (&most)
+			if now <= old || atomic.CompareAndSwapInt32 (&most, old, now) { break }
+		}
+		time.Sleep (time.Millisecond * 5)
+
+		if id == "missing" { return "", errors.Wrap (ErrNotFound, id) }
+		return id + "!", nil
+	})
+
+	assert.Equal (t, true, most <= 2, "only 2 should run at once")
+	assert.Equal (t, []string{"a!", "b!", "", "d!", "e!", "f!"}, results)
+
+	assert.Equal (t, 1, len(bad))
+	assert.Equal (t, ErrNotFound, errors.Cause (bad.For ("missing")))
+	assert.Nil (t, bad.For ("a"))
+	assert.Equal (t, ErrPartialBatch, errors.Cause (bad.err()))
+}
+
+func TestThrottle (t *testing.T) {
+	w := &Workiz { RateLimit: 50 } // one every 20ms
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := w.throttle (context.Background()); err != nil { t.Fatal(err) }
+	}
+	assert.Equal (t, true, time.Since (start) >= time.Millisecond * 80, "the first is free, then 4 waits")
+
+	// a finished context shouldn't wait
+	ctx, cancel := context.WithCancel (context.Background())
+	cancel()
+	w.throttle (ctx)
+	assert.Equal (t, context.Canceled, w.throttle (ctx))
+}
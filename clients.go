@@ -12,6 +12,7 @@ import (
     //"fmt"
     "net/http"
     "context"
+    "encoding/json"
     
 )
 
@@ -24,9 +25,22 @@ import (
 //-----------------------------------------------------------------------------------------------------------------------//
 
 type Client struct {
-    AuthSecret string `json:"auth_secret"`
+    AuthSecret string `json:"auth_secret,omitempty"`
     Id, FirstName, LastName, Address, City, State, Zip, Source, Email string `json:",omitempty"`
     AllowBilling bool 
+    Extra map[string]json.RawMessage `json:"-"` // anything workiz sent that we don't have a field for
+    drift []Drift
+}
+
+// keeps any unknown fields from Client/get in Extra
+func (this *Client) UnmarshalJSON (b []byte) error {
+    type alias Client // so we don't end up back in here
+    return decodeModel (b, (*alias)(this), "Client", &this.Extra, &this.drift)
+}
+
+// differences between the last response this was decoded from and our struct
+func (this *Client) SchemaDrift () []Drift {
+    return this.drift
 }
 
 type getClientResp struct {
@@ -54,7 +68,7 @@ func (this *Workiz) CreateClient (ctx context.Context, token, secret string, cli
         return errors.Errorf("response flag was not true : %+v", resp)
     }
 
-    client.Id = resp.Data[0].Client_id // copy this over
+    client.Id = resp.Data[0].Client_id.Value // copy this over
 
     return nil // we're good
 }
@@ -70,5 +84,7 @@ func (this *Workiz) GetClient (ctx context.Context, token, id string) (*Client,
         return nil, errors.Errorf("response flag was not true : %+v", resp)
     }
 
+    reportDrift (this, &resp.Data)
+
     return &resp.Data, nil // we're good
 }
@@ -0,0 +1,26 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"testing"
+	"encoding/json"
+)
+
+func TestClientMarshal (t *testing.T) {
+	// a new client doesn't have an id, so we don't send one
+	b, err := json.Marshal (&Client { FirstName: "A" })
+	if err != nil { t.Fatal(err) }
+	assert.NotContains (t, string(b), `"Id"`)
+	assert.Contains (t, string(b), `"FirstName":"A"`)
+
+	b, err = json.Marshal (Client { Id: "1002", FirstName: "A" })
+	if err != nil { t.Fatal(err) }
+	assert.Contains (t, string(b), `"Id":"1002"`)
+
+	// and it still reads back in
+	c := &Client{}
+	if err := json.Unmarshal (b, c); err != nil { t.Fatal(err) }
+	assert.Equal (t, "1002", c.Id)
+	assert.Equal (t, "A", c.FirstName)
+}
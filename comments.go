@@ -0,0 +1,132 @@
+/** ****************************************************************************************************************** **
+	Comments and notes on jobs and leads
+	the v1 api doesn't have a documented way to add a comment, so these are read only for now
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "encoding/json"
+    "sort"
+    "strings"
+    "time"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type NoteKind string
+
+const (
+    NoteKind_notes      = NoteKind("notes")     // the JobNotes/LeadNotes field
+    NoteKind_comment    = NoteKind("comment")
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type Comment struct {
+    Id, Text, Author string
+    CreatedDate time.Time
+}
+
+// workiz has used a few different names for these over time, so we check for all of them
+// also handles the comment just being a string
+func (this *Comment) UnmarshalJSON (b []byte) error {
+    var str string
+    if json.Unmarshal (b, &str) == nil {
+        *this = Comment { Text: str }
+        return nil
+    }
+
+    var data struct {
+        Id FlexString
+        Comment, Text string
+        CreatedBy, Author, User string
+        CreatedDate, Date, Created string
+    }
+    err := json.Unmarshal (b, &data)
+    if err != nil { return err }
+
+    *this = Comment { Id: data.Id.Value }
+    this.Text = firstNonEmpty (data.Comment, data.Text)
+    this.Author = firstNonEmpty (data.CreatedBy, data.Author, data.User)
+
+    if when := firstNonEmpty (data.CreatedDate, data.Date, data.Created); len(when) > 0 {
+        this.CreatedDate, _ = time.Parse ("2006-01-02 15:04:05", when) // a bad date just leaves it empty
+    }
+    return nil
+}
+
+type Comments []*Comment
+
+// an empty string means no comments, otherwise it's a list of them
+func (this *Comments) UnmarshalJSON (b []byte) error {
+    *this = nil
+
+    var str string
+    if json.Unmarshal (b, &str) == nil {
+        if len(strings.TrimSpace (str)) > 0 {
+            *this = append (*this, &Comment { Text: str })
+        }
+        return nil
+    }
+
+    var list []*Comment
+    err := json.Unmarshal (b, &list)
+    if err != nil { return err }
+
+    *this = list
+    return nil
+}
+
+// one entry in the combined history of notes and comments
+type Note struct {
+    Kind NoteKind
+    Text, Author string
+    Time time.Time
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+func firstNonEmpty (vals ...string) string {
+    for _, v := range vals {
+        if len(strings.TrimSpace (v)) > 0 { return v }
+    }
+    return ""
+}
+
+// the notes field doesn't have a timestamp, so it goes at the time the item was created
+func buildTimeline (notes, createdBy string, created time.Time, comments Comments) (ret []*Note) {
+    if len(strings.TrimSpace (notes)) > 0 {
+        ret = append (ret, &Note { Kind: NoteKind_notes, Text: notes, Author: createdBy, Time: created })
+    }
+
+    for _, c := range comments {
+        ret = append (ret, &Note { Kind: NoteKind_comment, Text: c.Text, Author: c.Author, Time: c.CreatedDate })
+    }
+
+    sort.SliceStable (ret, func (i, j int) bool {
+        return ret[i].Time.Before (ret[j].Time)
+    })
+    return
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// the job's notes and comments, oldest first
+func (this *Job) Timeline () []*Note {
+    return buildTimeline (this.JobNotes, this.CreatedBy, this.CreatedDate.Time, this.Comments)
+}
+
+// the lead's notes and comments, oldest first
+func (this *Lead) Timeline () []*Note {
+    return buildTimeline (this.LeadNotes, this.CreatedBy, this.CreatedDate.Time, this.Comments)
+}
@@ -0,0 +1,35 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"testing"
+	"encoding/json"
+)
+
+func TestComments (t *testing.T) {
+	job := &Job{}
+
+	err := json.Unmarshal ([]byte(`{"UUID":"OWX12J","CreatedDate":"2023-02-01 09:00:00","CreatedBy":"Nathan Thomas","JobNotes":"gate code 1234",
+		"Comments":[{"Id":5,"Comment":"second","CreatedBy":"Brooklyn Thomas","CreatedDate":"2023-02-03 10:00:00"},{"Comment":"first","User":"Nathan Thomas","Date":"2023-02-02 10:00:00"}]}`), job)
+	if err != nil { t.Fatal(err) }
+
+	assert.Equal (t, 2, len(job.Comments))
+	assert.Equal (t, "5", job.Comments[0].Id)
+	assert.Equal (t, "Brooklyn Thomas", job.Comments[0].Author)
+	assert.Equal (t, 3, job.Comments[0].CreatedDate.Day())
+
+	timeline := job.Timeline()
+	assert.Equal (t, 3, len(timeline))
+	assert.Equal (t, NoteKind_notes, timeline[0].Kind)
+	assert.Equal (t, "gate code 1234", timeline[0].Text)
+	assert.Equal (t, "first", timeline[1].Text)
+	assert.Equal (t, "second", timeline[2].Text)
+
+	// leads come back with an empty string when there aren't any
+	lead := &Lead{}
+	err = json.Unmarshal ([]byte(`{"UUID":"SRUYUI","Comments":""}`), lead)
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, 0, len(lead.Comments))
+	assert.Equal (t, 0, len(lead.Timeline()))
+}
@@ -0,0 +1,231 @@
+/** ****************************************************************************************************************** **
+	Schedule conflicts
+	Finds techs that are booked on overlapping jobs or leads, or don't have time to get from one to the next
+	and can be turned on as a pre-flight check so we refuse changes that would double book someone
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "github.com/pkg/errors"
+
+    "fmt"
+    "context"
+    "sort"
+    "strings"
+    "time"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type ConflictKind string
+
+const (
+    ConflictKind_overlap    = ConflictKind("overlap")   // booked on both at the same time
+    ConflictKind_travel     = ConflictKind("travel")    // not enough time to get from the first to the second
+)
+
+var ErrConflict = errors.New("Schedule conflict")
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type ConflictOptions struct {
+    TravelBuffer time.Duration // the least amount of time we want between appointments, no matter how close they are
+    AverageSpeed float64 // mph, used to estimate the drive between appointments with coordinates. 0 skips the estimate
+}
+
+type Conflict struct {
+    Member *Member
+    Kind ConflictKind
+    First, Second string // the job or lead uuids, in the order they start
+    Gap time.Duration // from the end of the first to the start of the second, negative when they overlap
+    Needed time.Duration // how much of a gap we wanted, for travel conflicts
+}
+
+func (this *Conflict) String () string {
+    if this.Kind == ConflictKind_overlap {
+        return fmt.Sprintf ("%s is booked on %s and %s at the same time", this.Member.Name, this.First, this.Second)
+    }
+    return fmt.Sprintf ("%s has %s between %s and %s but needs %s", this.Member.Name, this.Gap, this.First, this.Second, this.Needed)
+}
+
+// a list of conflicts is also an error, errors.Cause() gives ErrConflict
+type Conflicts []*Conflict
+
+func (this Conflicts) Error () string {
+    msgs := make([]string, 0, len(this))
+    for _, c := range this { msgs = append (msgs, c.String()) }
+    return fmt.Sprintf ("%s : %s", ErrConflict.Error(), strings.Join (msgs, " | "))
+}
+
+func (this Conflicts) Cause () error { return ErrConflict }
+func (this Conflicts) Unwrap () error { return ErrConflict }
+
+func (this Conflicts) err () error {
+    if len(this) == 0 { return nil }
+    return this
+}
+
+// jobs and leads both take up a tech's time, this lets us treat them the same
+type appointment struct {
+    id string
+    start, end time.Time
+    location Point
+    crew []string // member ids
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// turns the scheduled jobs and leads into appointments, unscheduled ones are skipped
+func toAppointments (jobs []*Job, leads []*Lead) (ret []*appointment) {
+    for _, j := range jobs {
+        if j.JobDateTime.IsZero() { continue }
+
+        a := &appointment { id: j.UUID, location: Point { Lat: j.Latitude.Value, Lng: j.Longitude.Value } }
+        a.start, a.end = j.Window()
+        for _, t := range j.Team { a.crew = append (a.crew, t.Id.Value) }
+        ret = append (ret, a)
+    }
+
+    for _, l := range leads {
+        if l.LeadDateTime.IsZero() { continue }
+
+        a := &appointment { id: l.UUID, location: Point { Lat: l.Latitude.Value, Lng: l.Longitude.Value } }
+        a.start, a.end = l.Window()
+        for _, t := range l.Team { a.crew = append (a.crew, t.Id.Value) }
+        ret = append (ret, a)
+    }
+    return
+}
+
+func (this *appointment) hasMember (id string) bool {
+    for _, c := range this.crew {
+        if c == id { return true }
+    }
+    return false
+}
+
+// a member's appointments, in the order they start
+func memberAppointments (m *Member, appts []*appointment) (ret []*appointment) {
+    for _, a := range appts {
+        if a.hasMember (m.Id) { ret = append (ret, a) }
+    }
+    sort.SliceStable (ret, func (i, j int) bool { return ret[i].start.Before (ret[j].start) })
+    return
+}
+
+// the gap we want between 2 appointments, the buffer or the drive, whichever is longer
+func travelNeeded (from, to *appointment, opts ConflictOptions) time.Duration {
+    needed := opts.TravelBuffer
+    if from.location.Valid() && to.location.Valid() {
+        if drive := DriveTime (DistanceMiles (from.location, to.location), opts.AverageSpeed); drive > needed {
+            needed = drive
+        }
+    }
+    return needed
+}
+
+func findConflicts (members Members, appts []*appointment, opts ConflictOptions) (ret Conflicts) {
+    for _, m := range members {
+        mine := memberAppointments (m, appts)
+
+        for i, a := range mine {
+            // anything that starts before this one ends is an overlap
+            for _, b := range mine[i+1:] {
+                if b.start.Before (a.end) == false { break }
+                ret = append (ret, &Conflict { Member: m, Kind: ConflictKind_overlap, First: a.id, Second: b.id, Gap: b.start.Sub (a.end) })
+            }
+
+            // and the next one needs to leave time for the drive
+            if i + 1 < len(mine) {
+                b := mine[i+1]
+                gap := b.start.Sub (a.end)
+                if gap < 0 { continue } // already reported as an overlap
+
+                if needed := travelNeeded (a, b, opts); gap < needed {
+                    ret = append (ret, &Conflict { Member: m, Kind: ConflictKind_travel, First: a.id, Second: b.id, Gap: gap, Needed: needed })
+                }
+            }
+        }
+    }
+    return
+}
+
+// the range to pull around a change, as wall clock time like everything from workiz
+// it goes back to the start of the day before, so a job that started last night and runs into this one still shows up
+// the lists are exclusive on both ends, so it's a second wider each way
+func preflightRange (start, end time.Time) (time.Time, time.Time) {
+    start, end = wallClock (start, start.Location()), wallClock (end, end.Location())
+    if end.Before (start) { end = start }
+
+    from := atClock (start.AddDate (0, 0, -1), 0).Add (-time.Second)
+    to := atClock (end.AddDate (0, 0, 1), 0).Add (time.Second)
+    return from, to
+}
+
+// where a job is going to be, for preflight
+// the times passed in are in the account's timezone, the schedule is wall clock, so this lines them up
+func preflightChange (jobId string, start, end time.Time, crew Members, location Point) *appointment {
+    ret := &appointment { id: jobId, start: wallClock (start, start.Location()), end: wallClock (end, end.Location()), location: location }
+    if start.IsZero() { ret.start, ret.end = time.Time{}, time.Time{} }
+
+    for _, c := range crew { ret.crew = append (ret.crew, c.Id) }
+    return ret
+}
+
+// checks changes to one or more jobs against everything else booked around them
+// each job's current version in the schedule is replaced by its change, so a set of jobs moving together doesn't trip over itself
+func (this *Workiz) preflight (ctx context.Context, token string, changes []*appointment, crew Members) error {
+    if this.Preflight == nil || len(crew) == 0 { return nil }
+
+    changed := make(map[string]bool)
+    var from, to time.Time
+    for _, c := range changes {
+        if c.start.IsZero() { continue } // nothing to check
+
+        f, t := preflightRange (c.start, c.end)
+        if len(changed) == 0 || f.Before (from) { from = f }
+        if len(changed) == 0 || t.After (to) { to = t }
+        changed[c.id] = true
+    }
+    if len(changed) == 0 { return nil }
+
+    // records we couldn't decode aren't going to help us here, but they shouldn't stop the check
+    jobs, _, err := this.ListJobsPartial (ctx, token, from, to)
+    if err != nil { return err }
+
+    leads, _, err := this.ListLeadsPartial (ctx, token, from, to)
+    if err != nil { return err }
+
+    appts := []*appointment{}
+    for _, a := range toAppointments (jobs, leads) {
+        if changed[a.id] == false { appts = append (appts, a) }
+    }
+    for _, c := range changes {
+        if changed[c.id] { appts = append (appts, c) }
+    }
+
+    // we only care about problems these changes are part of
+    var ret Conflicts
+    for _, c := range findConflicts (crew, appts, *this.Preflight) {
+        if changed[c.First] || changed[c.Second] { ret = append (ret, c) }
+    }
+    return ret.err()
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// finds every member who's double booked, or can't make it from one appointment to the next in time
+// jobs and leads without a date are ignored
+func FindConflicts (members Members, jobs []*Job, leads []*Lead, opts ConflictOptions) Conflicts {
+    return findConflicts (members, toAppointments (jobs, leads), opts)
+}
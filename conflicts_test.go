@@ -0,0 +1,65 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/pkg/errors"
+
+	"testing"
+	"encoding/json"
+	"time"
+)
+
+func TestFindConflicts (t *testing.T) {
+	jobs := []*Job {
+		testJob (t, "AAA111", "2023-02-28 09:00", 60, 44.3998, -73.2037, "1"), // shelburne
+		testJob (t, "BBB222", "2023-02-28 09:30", 60, 44.3998, -73.2037, "1", "2"), // overlaps AAA111 for 1
+		testJob (t, "CCC333", "2023-02-28 10:35", 60, 44.2601, -72.5754, "1"), // montpelier, 5 minutes after BBB222
+		testJob (t, "DDD444", "2023-02-28 12:00", 60, 44.2601, -72.5754, "2"),
+		testJob (t, "EEE555", "", 60, 0, 0, "2"), // unscheduled, ignored
+	}
+
+	lead := &Lead{}
+	err := json.Unmarshal ([]byte(`{"UUID":"LLL111","LeadDateTime":"2023-02-28 12:30:00","LeadEndDateTime":"2023-02-28 13:00:00","Team":[{"id":"2","name":"tech 2"}]}`), lead)
+	if err != nil { t.Fatal(err) }
+
+	conflicts := FindConflicts (testMembers(), jobs, []*Lead{ lead }, ConflictOptions { TravelBuffer: 15 * time.Minute, AverageSpeed: 40 })
+	assert.Equal (t, 3, len(conflicts))
+
+	// 1 is on 2 jobs at once
+	assert.Equal (t, ConflictKind_overlap, conflicts[0].Kind)
+	assert.Equal (t, "1", conflicts[0].Member.Id)
+	assert.Equal (t, "AAA111", conflicts[0].First)
+	assert.Equal (t, "BBB222", conflicts[0].Second)
+	assert.Equal (t, -30 * time.Minute, conflicts[0].Gap)
+
+	// then can't make it to montpelier in 5 minutes, it's more like 50 at 40mph
+	assert.Equal (t, ConflictKind_travel, conflicts[1].Kind)
+	assert.Equal (t, "CCC333", conflicts[1].Second)
+	assert.Equal (t, true, conflicts[1].Needed > 45 * time.Minute)
+
+	// 2 has plenty of time to get from BBB222 to DDD444, but the lead overlaps DDD444
+	assert.Equal (t, ConflictKind_overlap, conflicts[2].Kind)
+	assert.Equal (t, "2", conflicts[2].Member.Id)
+	assert.Equal (t, "DDD444", conflicts[2].First)
+	assert.Equal (t, "LLL111", conflicts[2].Second)
+
+	assert.Equal (t, ErrConflict, errors.Cause (conflicts.err()))
+	assert.Nil (t, Conflicts(nil).err())
+}
+
+func TestPreflightRange (t *testing.T) {
+	ny, err := time.LoadLocation ("America/New_York")
+	if err != nil { t.Fatal(err) }
+
+	// 9pm in new york is already the next day in utc, the range should still be new york's days
+	start := time.Date (2023, 2, 28, 21, 0, 0, 0, ny)
+	from, to := preflightRange (start, start.Add (2 * time.Hour))
+
+	// back to the start of the day before, for anything that ran overnight into this one
+	assert.Equal (t, time.Date (2023, 2, 26, 23, 59, 59, 0, time.UTC), from)
+	assert.Equal (t, time.Date (2023, 3, 1, 0, 0, 1, 0, time.UTC), to)
+
+	// a job that started the night before and runs late is caught
+	overnight := testJob (t, "AAA111", "2023-02-27 22:00", 24 * 60, 0, 0, "1")
+	assert.Equal (t, true, overnight.JobDateTime.After (from) && overnight.JobDateTime.Before (to))
+}
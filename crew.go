@@ -0,0 +1,234 @@
+/** ****************************************************************************************************************** **
+	Crew assignment planning
+	Figures out who needs to be added to or removed from a job or lead before anything is sent
+	so the caller can look at the plan (dry run) or apply it
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "github.com/pkg/errors"
+
+    "context"
+    "time"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type CrewAction string
+
+const (
+    CrewAction_add          = CrewAction("add")
+    CrewAction_remove       = CrewAction("remove")
+)
+
+// how long a rollback gets on its own, it doesn't use the caller's context since that's often what ran out
+const crewRollbackTimeout = time.Minute
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// the difference between who's on a job now and who should be
+type CrewPlan struct {
+    Id string // the job or lead this is for
+    Adds, Removes Members
+    Keeps Members // already assigned and staying, nothing to do
+    Stale Members // assigned, but not on the team roster anymore, so we don't know their current name and leave them alone
+    Unknown []string // names or ids we were asked for that don't match anyone on the team, or match more than one
+    Matches map[string]*NameMatch // how each wanted name was matched, for anything that wasn't an id
+
+    // which calls to use, set when the plan is made for a job or a lead
+    assign assignCrew
+    unassign unassignCrew
+}
+
+// true if applying this would actually send anything
+func (this *CrewPlan) HasChanges () bool {
+    return len(this.Adds) > 0 || len(this.Removes) > 0
+}
+
+// how a single assign or unassign went
+type CrewResult struct {
+    Member *Member
+    Action CrewAction
+    Err error
+    RolledBack bool // it worked, but was undone because something else in the plan failed
+}
+
+type CrewOptions struct {
+    Concurrency int // how many assign/unassign calls can be in flight, defaults to 4
+    Rollback bool // if anything fails, undo what did work so the crew is back the way it was
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// names need to match at least this well before we'll assign someone based on them
+// anything looser, like just a last name or a typo, could put the wrong person on a job
+const crewMatchConfidence = MatchConfidence_nickname
+
+// compares who's assigned with who we want
+// the wanted list can be ids or names, ids are checked first
+// everything is compared by id, since the name stored on the job may not be the member's current name
+func planCrew (existingTeam []*teamGeneric, team Members, wanted []string) *CrewPlan {
+    plan := &CrewPlan { Matches: make(map[string]*NameMatch) }
+    index := team.Index()
+
+    desired := make(map[string]bool) // by id
+    for _, w := range wanted {
+        m := team.ById (w)
+        if m == nil {
+            match := index.Lookup (w)
+            plan.Matches[w] = match
+            if match.Found (crewMatchConfidence) { m = match.Member }
+        }
+
+        if m == nil {
+            plan.Unknown = append (plan.Unknown, w)
+            continue
+        }
+        if desired[m.Id] { continue } // asked for twice
+        desired[m.Id] = true
+
+        assigned := false
+        for _, e := range existingTeam {
+            if e.Id == m.Id {
+                assigned = true
+                break
+            }
+        }
+
+        if assigned {
+            plan.Keeps.Push (m)
+        } else {
+            plan.Adds.Push (m)
+        }
+    }
+
+    for _, e := range existingTeam {
+        if desired[e.Id] { continue } // already handled as a keep
+
+        m := team.ById (e.Id)
+        if m == nil {
+            plan.Stale.Push (&Member { Id: e.Id, Name: e.Name })
+            continue
+        }
+        plan.Removes.Push (m)
+    }
+    return plan
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// works out what UpdateJobCrew would do without changing anything
+// wanted can be a mix of member ids and names
+func (this *Workiz) PlanJobCrew (ctx context.Context, token, jobId string, team Members, wanted []string) (*CrewPlan, error) {
+    existing, err := this.GetJob (ctx, token, jobId)
+    if err != nil { return nil, err }
+
+    plan := planCrew (existing.toGeneric(), team, wanted)
+    plan.Id, plan.assign, plan.unassign = jobId, this.AssignJobCrew, this.UnassignJobCrew
+    return plan, nil
+}
+
+// works out what UpdateLeadCrew would do without changing anything
+func (this *Workiz) PlanLeadCrew (ctx context.Context, token, leadId string, team Members, wanted []string) (*CrewPlan, error) {
+    existing, err := this.GetLead (ctx, token, leadId)
+    if err != nil { return nil, err }
+
+    plan := planCrew (existing.toGeneric(), team, wanted)
+    plan.Id, plan.assign, plan.unassign = leadId, this.AssignLeadCrew, this.UnassignLeadCrew
+    return plan, nil
+}
+
+// sends the adds, then the removes, by the member's current name
+// each group is sent concurrently and everything is attempted, the results say how each member went
+// any failures come back together as BatchErrors, keyed by member id
+// with Rollback set, the removes are skipped once an add fails, and anything that worked is undone
+func (this *Workiz) ApplyCrewPlan (ctx context.Context, token, secret string, plan *CrewPlan, opts CrewOptions) ([]*CrewResult, error) {
+    if plan.assign == nil || plan.unassign == nil {
+        return nil, errors.Wrap (ErrUnexpected, "crew plan needs to come from PlanJobCrew or PlanLeadCrew")
+    }
+
+    ret := this.applyCrew (ctx, token, secret, plan, plan.Adds, CrewAction_add, opts.Concurrency)
+
+    if opts.Rollback == false || crewFailed (ret) == false {
+        ret = append (ret, this.applyCrew (ctx, token, secret, plan, plan.Removes, CrewAction_remove, opts.Concurrency)...)
+    }
+
+    var bad BatchErrors
+    for _, r := range ret {
+        if r.Err != nil { bad = append (bad, &BatchError { Id: r.Member.Id, Err: r.Err }) }
+    }
+
+    if opts.Rollback && len(bad) > 0 {
+        bad = append (bad, this.rollbackCrew (token, secret, plan, ret, opts.Concurrency)...)
+    }
+    return ret, bad.err()
+}
+
+// sends the same action for a group of members, at the same time
+func (this *Workiz) applyCrew (ctx context.Context, token, secret string, plan *CrewPlan, members Members, action CrewAction, concurrency int) []*CrewResult {
+    ret := make([]*CrewResult, len(members))
+    started := make([]bool, len(members))
+
+    fn := plan.assign
+    if action == CrewAction_remove { fn = assignCrew(plan.unassign) }
+
+    runPool (ctx, len(members), concurrency, func (i int) {
+        started[i] = true
+        err := fn (ctx, token, secret, plan.Id, members[i].Name)
+        if err != nil { err = errors.Wrapf (err, "%s %s", action, members[i].Name) }
+        ret[i] = &CrewResult { Member: members[i], Action: action, Err: err }
+    })
+
+    for i, m := range members {
+        if started[i] == false { ret[i] = &CrewResult { Member: m, Action: action, Err: ctx.Err() } } // ran out of time
+    }
+    return ret
+}
+
+// undoes everything that worked, adds get removed and removes get added back
+// returns anything we couldn't undo, since that leaves the crew in a state nobody asked for
+// this runs on a fresh context, if the caller's timed out we still want the crew put back
+func (this *Workiz) rollbackCrew (token, secret string, plan *CrewPlan, results []*CrewResult, concurrency int) (bad BatchErrors) {
+    ctx, cancel := context.WithTimeout (context.Background(), crewRollbackTimeout)
+    defer cancel()
+
+    var undo []*CrewResult
+    for _, r := range results {
+        if r.Err == nil { undo = append (undo, r) }
+    }
+
+    errs := make([]error, len(undo))
+    runPool (ctx, len(undo), concurrency, func (i int) {
+        fn := plan.unassign
+        if undo[i].Action == CrewAction_remove { fn = unassignCrew(plan.assign) }
+
+        errs[i] = fn (ctx, token, secret, plan.Id, undo[i].Member.Name)
+        if errs[i] == nil { undo[i].RolledBack = true }
+    })
+
+    for i, r := range undo {
+        if r.RolledBack { continue }
+
+        err := errs[i]
+        if err == nil { err = ctx.Err() } // never got to it
+        bad = append (bad, &BatchError { Id: r.Member.Id, Err: errors.Wrapf (err, "rollback %s %s", r.Action, r.Member.Name) })
+    }
+    return
+}
+
+func crewFailed (results []*CrewResult) bool {
+    for _, r := range results {
+        if r.Err != nil { return true }
+    }
+    return false
+}
@@ -0,0 +1,161 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/pkg/errors"
+
+	"testing"
+	"context"
+	"sync"
+	stdErrors "errors"
+)
+
+func testTeam () Members {
+	return Members {
+		&Member { Id: "228777", Name: "Nathan Thomas" },
+		&Member { Id: "246389", Name: "Brooklyn Thomas" },
+		&Member { Id: "300001", Name: "Alissa Thomas" },
+	}
+}
+
+func TestCrewPlan (t *testing.T) {
+	existing := []*teamGeneric {
+		{ Id: "228777", Name: "Nate Thomas" }, // renamed since they were assigned
+		{ Id: "246389", Name: "Brooklyn Thomas" },
+		{ Id: "999999", Name: "Someone Gone" },
+	}
+
+	plan := planCrew (existing, testTeam(), []string{" nathan  thomas", "300001", "Nobody", "Alissa Thomas", "Thomas"})
+
+	assert.Equal (t, true, plan.HasChanges())
+	assert.Equal (t, 1, len(plan.Adds))
+	assert.Equal (t, "Alissa Thomas", plan.Adds[0].Name)
+	assert.Equal (t, 1, len(plan.Keeps))
+	assert.Equal (t, "228777", plan.Keeps[0].Id)
+	assert.Equal (t, 1, len(plan.Removes))
+	assert.Equal (t, "Brooklyn Thomas", plan.Removes[0].Name)
+	assert.Equal (t, 1, len(plan.Stale))
+	assert.Equal (t, []string{"Nobody", "Thomas"}, plan.Unknown)
+	assert.Equal (t, true, plan.Matches["Thomas"].Ambiguous)
+}
+
+func TestApplyCrewPlan (t *testing.T) {
+	w := &Workiz{}
+	lock := &sync.Mutex{}
+	var calls []string
+
+	plan := planCrew ([]*teamGeneric{{ Id: "246389" }}, testTeam(), []string{"Nathan Thomas", "Alissa Thomas"})
+	plan.Id = "OWX12J"
+	plan.assign = func (ctx context.Context, token, secret, id, name string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		calls = append (calls, "add " + name)
+		return nil
+	}
+	plan.unassign = func (ctx context.Context, token, secret, id, name string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		calls = append (calls, "remove " + name)
+		return errors.Wrap (ErrUnexpected, "nope")
+	}
+
+	// everything gets attempted, and the failure comes back with the member's id
+	results, err := w.ApplyCrewPlan (context.Background(), "token", "secret", plan, CrewOptions{})
+	assert.Equal (t, ErrPartialBatch, errors.Cause (err))
+	assert.Equal (t, 3, len(calls))
+	assert.Equal (t, "remove Brooklyn Thomas", calls[2])
+	assert.Equal (t, 3, len(results))
+	assert.Nil (t, results[0].Err)
+	assert.Nil (t, results[1].Err)
+	assert.Equal (t, CrewAction_remove, results[2].Action)
+
+	var bad BatchErrors
+	assert.Equal (t, true, stdErrors.As (err, &bad))
+	assert.Equal (t, 1, len(bad))
+	assert.Equal (t, ErrUnexpected, errors.Cause (bad.For ("246389")))
+
+	// a plan that didn't come from PlanJobCrew can't be applied
+	_, err = w.ApplyCrewPlan (context.Background(), "token", "secret", &CrewPlan{}, CrewOptions{})
+	assert.Error (t, err)
+}
+
+func TestApplyCrewPlanRollback (t *testing.T) {
+	w := &Workiz{}
+	lock := &sync.Mutex{}
+	var calls []string
+
+	plan := planCrew ([]*teamGeneric{{ Id: "246389" }}, testTeam(), []string{"Nathan Thomas", "Alissa Thomas"})
+	plan.Id = "OWX12J"
+	plan.assign = func (ctx context.Context, token, secret, id, name string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		calls = append (calls, "add " + name)
+		if name == "Alissa Thomas" { return errors.Wrap (ErrUnexpected, "nope") }
+		return nil
+	}
+	plan.unassign = func (ctx context.Context, token, secret, id, name string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		calls = append (calls, "remove " + name)
+		return nil
+	}
+
+	results, err := w.ApplyCrewPlan (context.Background(), "token", "secret", plan, CrewOptions { Rollback: true })
+	assert.Error (t, err)
+
+	// Brooklyn never gets removed, and Nathan gets taken back off
+	assert.Equal (t, 3, len(calls))
+	assert.Equal (t, "remove Nathan Thomas", calls[2])
+	assert.Equal (t, 2, len(results))
+	assert.Equal (t, true, results[0].RolledBack)
+	assert.Equal (t, false, results[1].RolledBack)
+
+	// the caller's context running out is usually why the add failed, the rollback still needs to happen
+	calls = nil
+	ctx, cancel := context.WithCancel (context.Background())
+	plan.assign = func (c context.Context, token, secret, id, name string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		calls = append (calls, "add " + name)
+		if name == "Alissa Thomas" {
+			cancel()
+			return errors.WithStack (context.Canceled)
+		}
+		return nil
+	}
+
+	results, err = w.ApplyCrewPlan (ctx, "token", "secret", plan, CrewOptions { Rollback: true, Concurrency: 1 })
+	assert.Error (t, err)
+	assert.Equal (t, 3, len(calls))
+	assert.Equal (t, "remove Nathan Thomas", calls[2])
+	assert.Equal (t, true, results[0].RolledBack)
+}
+
+func TestHandleCrewSingleFailure (t *testing.T) {
+	w := &Workiz{}
+	assign := func (ctx context.Context, token, secret, id, name string) error {
+		return errors.Wrap (ErrAuthExpired, "nope")
+	}
+	unassign := func (ctx context.Context, token, secret, id, name string) error { return nil }
+
+	// just the one add fails, so callers can still check for the auth problem
+	err := w.handleCrew (context.Background(), []*teamGeneric{{ Id: "246389" }}, "token", "secret", "OWX12J", testTeam(),
+		[]string{"Brooklyn Thomas", "Nathan Thomas"}, assign, unassign)
+	assert.Equal (t, ErrAuthExpired, errors.Cause (err))
+}
+
+func TestHandleCrewUnknown (t *testing.T) {
+	w := &Workiz{}
+	var calls []string
+	record := func (ctx context.Context, token, secret, id, name string) error {
+		calls = append (calls, name)
+		return nil
+	}
+
+	// Brooklyn would have been removed to make room for whoever "Nobody" was meant to be
+	err := w.handleCrew (context.Background(), []*teamGeneric{{ Id: "246389" }}, "token", "secret", "OWX12J", testTeam(),
+		[]string{"Nathan Thomas", "Nobody"}, record, record)
+	assert.Equal (t, ErrNotFound, errors.Cause (err))
+	assert.Contains (t, err.Error(), "Nobody")
+	assert.Equal (t, 0, len(calls))
+}
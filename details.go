@@ -0,0 +1,183 @@
+/** ****************************************************************************************************************** **
+	The extra pieces that come back on a job from job/get
+	Line items, payments, tags and custom fields
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "encoding/json"
+    "strconv"
+    "strings"
+    "time"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type LineItem struct {
+    Id FlexString
+    Name, Description string
+    Quantity FlexFloat
+    Price, Cost Money
+    Taxable FlexBool
+}
+
+// price times quantity, to the cent
+func (this *LineItem) Total () Money {
+    qty := this.Quantity.Value
+    if qty == 0 { qty = 1 } // workiz leaves this empty when it's a single item
+
+    ret, err := this.Price.MulRate (strconv.FormatFloat (qty, 'f', -1, 64))
+    if err != nil { return this.Price } // can't really happen, the float always formats
+    return ret
+}
+
+type Payment struct {
+    Id FlexString
+    Amount Money
+    Method string `json:"Type"`
+    Date workizTime
+    Reference string
+}
+
+// the breakdown of what's on the invoice for a job
+type InvoiceTotals struct {
+    LineItems, SubTotal, Tax, Discount, Total, Paid, AmountDue Money
+}
+
+// workiz sends tags as a list, a comma separated string, or a list of objects, depending on the endpoint
+type Tags []string
+
+func (this *Tags) UnmarshalJSON (b []byte) error {
+    *this = nil
+
+    var list []string
+    if json.Unmarshal (b, &list) == nil {
+        for _, t := range list { this.add (t) }
+        return nil
+    }
+
+    var str string
+    if json.Unmarshal (b, &str) == nil {
+        for _, t := range strings.Split (str, ",") { this.add (t) }
+        return nil
+    }
+
+    var objs []struct {
+        Name, Tag string
+    }
+    err := json.Unmarshal (b, &objs)
+    if err != nil { return err }
+
+    for _, o := range objs {
+        this.add (o.Name)
+        this.add (o.Tag)
+    }
+    return nil
+}
+
+func (this *Tags) add (t string) {
+    t = strings.TrimSpace (t)
+    if len(t) > 0 { *this = append (*this, t) }
+}
+
+func (this Tags) Has (tag string) bool {
+    for _, t := range this {
+        if strings.EqualFold (t, tag) { return true }
+    }
+    return false
+}
+
+// custom fields are whatever the account set up, so we keep the raw json and convert it when asked
+// keys are matched case insensitively
+type CustomFields map[string]json.RawMessage
+
+// accepts either an object of key/values or a list of {name, value} objects
+func (this *CustomFields) UnmarshalJSON (b []byte) error {
+    *this = make(CustomFields)
+
+    var obj map[string]json.RawMessage
+    if json.Unmarshal (b, &obj) == nil {
+        for key, val := range obj { (*this)[key] = val }
+        return nil
+    }
+
+    var list []struct {
+        Name, Key string
+        Value json.RawMessage
+    }
+    err := json.Unmarshal (b, &list)
+    if err != nil { return err }
+
+    for _, f := range list {
+        key := f.Name
+        if len(key) == 0 { key = f.Key }
+        if len(key) > 0 { (*this)[key] = f.Value }
+    }
+    return nil
+}
+
+func (this CustomFields) raw (key string) (json.RawMessage, bool) {
+    if val, ok := this[key]; ok { return val, true }
+
+    for k, val := range this {
+        if strings.EqualFold (k, key) { return val, true }
+    }
+    return nil, false
+}
+
+func (this CustomFields) Has (key string) bool {
+    _, ok := this.raw (key)
+    return ok
+}
+
+// returns the field as a string, false if it's not there
+func (this CustomFields) String (key string) (string, bool) {
+    var val FlexString
+    ok := this.decode (key, &val)
+    return val.Value, ok
+}
+
+func (this CustomFields) Int (key string) (int64, bool) {
+    var val FlexInt
+    ok := this.decode (key, &val)
+    return val.Value, ok
+}
+
+func (this CustomFields) Float (key string) (float64, bool) {
+    var val FlexFloat
+    ok := this.decode (key, &val)
+    return val.Value, ok
+}
+
+func (this CustomFields) Bool (key string) (bool, bool) {
+    var val FlexBool
+    ok := this.decode (key, &val)
+    return val.Value, ok
+}
+
+func (this CustomFields) Money (key string) (Money, bool) {
+    var val Money
+    ok := this.decode (key, &val)
+    return val, ok
+}
+
+func (this CustomFields) Time (key string) (time.Time, bool) {
+    var val workizTime
+    ok := this.decode (key, &val)
+    return val.Time, ok
+}
+
+// false if the key is missing or the value couldn't be converted
+func (this CustomFields) decode (key string, out interface{}) bool {
+    raw, ok := this.raw (key)
+    if ok == false { return false }
+
+    if json.Unmarshal (raw, out) != nil { return false }
+
+    if w, ok := out.(flexWarner); ok && len(w.decodeWarning()) > 0 { return false }
+    return true
+}
@@ -0,0 +1,54 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"testing"
+	"encoding/json"
+)
+
+func TestJobDetails (t *testing.T) {
+	resp := &jobResponse{}
+
+	err := json.Unmarshal([]byte(`{"flag":true,"data":[{"UUID":"OWX12J","JobTotalPrice":"53.25","JobAmountDue":"13.25","SubTotal":"50.00","Tax":"3.25",
+		"LineItems":[{"Id":12,"Name":"Growler Fill","Quantity":"2","Price":"20.00","Taxable":1},{"Id":"13","Name":"Delivery","Price":10}],
+		"Payments":[{"Id":"p1","Amount":"40.00","Type":"credit","Date":"2023-02-28 12:00:00","Reference":"ch_123"}],
+		"Tags":"vip, weekly",
+		"CustomFields":[{"name":"Gate Code","value":"1234"},{"name":"Kegs","value":"3"},{"name":"Delivered","value":"2023-02-28 13:00:00"}]}]}`), resp)
+	if err != nil { t.Fatal(err) }
+	if len(resp.Data) != 1 { t.Fatal(resp.errs) }
+
+	job := resp.Data[0]
+	assert.Equal (t, 2, len(job.LineItems))
+	assert.Equal (t, "40.00", job.LineItems[0].Total().String())
+	assert.Equal (t, true, job.LineItems[0].Taxable.Value)
+	assert.Equal (t, "10.00", job.LineItems[1].Total().String())
+
+	assert.Equal (t, 1, len(job.Payments))
+	assert.Equal (t, "credit", job.Payments[0].Method)
+	assert.Equal (t, 2023, job.Payments[0].Date.Year())
+
+	assert.Equal (t, true, job.Tags.Has("VIP"))
+	assert.Equal (t, "weekly", job.Tags[1])
+
+	code, ok := job.CustomFields.String ("gate code")
+	assert.Equal (t, true, ok)
+	assert.Equal (t, "1234", code)
+
+	kegs, ok := job.CustomFields.Int ("Kegs")
+	assert.Equal (t, true, ok)
+	assert.Equal (t, int64(3), kegs)
+
+	delivered, ok := job.CustomFields.Time ("Delivered")
+	assert.Equal (t, true, ok)
+	assert.Equal (t, 13, delivered.Hour())
+
+	_, ok = job.CustomFields.Float ("Gate Code ")
+	assert.Equal (t, false, ok)
+
+	totals := job.Totals()
+	assert.Equal (t, "50.00", totals.LineItems.String())
+	assert.Equal (t, "40.00", totals.Paid.String())
+	assert.Equal (t, "3.25", totals.Tax.String())
+	assert.Equal (t, "13.25", totals.AmountDue.String())
+}
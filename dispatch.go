@@ -0,0 +1,343 @@
+/** ****************************************************************************************************************** **
+	Dispatching
+	Spreads a day's unassigned jobs across the team, respecting shifts, appointment windows, skills
+	and how many jobs a tech can take, then turns the result into the workiz calls to make it happen
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "github.com/pkg/errors"
+
+    "context"
+    "sort"
+    "time"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type DispatchCallKind string
+
+const (
+    DispatchCall_schedule   = DispatchCallKind("schedule")  // UpdateJobSchedule
+    DispatchCall_crew       = DispatchCallKind("crew")      // UpdateJobCrew
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type DispatchOptions struct {
+    Day time.Time // the date being planned, the shift is on the account's clock like the job times from workiz
+    Hours BusinessHours // the shift, only the open and close times are used. defaults to 8 to 5
+    Depot Point // where everyone starts, when it's not valid the first drive of the day isn't counted
+    Depots map[string]Point // starting points by member id, for techs who take their truck home
+    ReturnToDepot bool // techs need to be back at their depot by the end of the shift
+    AverageSpeed float64 // mph, 0 doesn't leave any time for driving
+    JobDuration time.Duration // how long jobs take, when it's 0 or longer than the job's window we use the whole window
+    MaxJobs int // most jobs a tech can have, including what they're already booked on. 0 for no limit
+    Booked []*Job // what the team already has that day, with their crews. it stays put and new work goes around it
+    BookedLeads []*Lead
+}
+
+// a job's place in a tech's day
+type DispatchStop struct {
+    Job *Job
+    Lead *Lead // for booked leads, Job is nil for these
+    Booked bool // it was already on their schedule, so there's nothing to send for it
+    Location Point
+    Distance float64 // miles from the previous stop, or the depot
+    Drive time.Duration
+    Arrive time.Time // when they get there, they may have to wait for the window to open
+    Start, End time.Time // when the work is scheduled
+}
+
+type DispatchRoute struct {
+    Member *Member
+    Depot Point // where they start the day, not valid when we don't know
+    Stops []*DispatchStop // new and booked work, in the order they get to it
+    Distance float64 // total miles, including the drive back to the depot when ReturnToDepot is set
+    Drive time.Duration
+}
+
+// one call to make to workiz
+type DispatchCall struct {
+    Kind DispatchCallKind
+    JobId string
+    Start time.Time // for schedule calls
+    Duration time.Duration
+    Crew []string // names, for crew calls
+}
+
+type DispatchPlan struct {
+    Routes []*DispatchRoute // one for each tech who could take work, even if they didn't get any
+    Unassigned []*Job // jobs we couldn't fit anywhere
+    Skipped []*Job // jobs without coordinates, so we can't route them
+    Calls []*DispatchCall // in the order to make them, each job's schedule comes before its crew
+
+    team Members
+}
+
+// a job along with when it can be worked
+// booked work has a duration that fills its window, so it can't move
+type dispatchJob struct {
+    job *Job
+    lead *Lead
+    booked bool
+    location Point // not valid for booked work we don't have coordinates for
+    earliest, latest time.Time // the work needs to fit between these
+    duration time.Duration
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// the shift for the day being planned, as wall clock time labelled utc so it lines up with the jobs
+func (this DispatchOptions) shift () (time.Time, time.Time) {
+    openAt, closeAt := this.Hours.hours()
+    y, m, d := this.Day.Date()
+    day := time.Date (y, m, d, 0, 0, 0, 0, time.UTC)
+    return atClock (day, openAt), atClock (day, closeAt)
+}
+
+// what each member is already booked on during the shift, in the order it starts
+// anything that's also in the jobs being dispatched is left out, it's getting moved
+func (this DispatchOptions) booked (members Members, moving map[string]bool, shiftStart, shiftEnd time.Time) map[string][]*dispatchJob {
+    jobs := make(map[string]*Job, len(this.Booked))
+    for _, j := range this.Booked { jobs[j.UUID] = j }
+
+    leads := make(map[string]*Lead, len(this.BookedLeads))
+    for _, l := range this.BookedLeads { leads[l.UUID] = l }
+
+    appts := toAppointments (this.Booked, this.BookedLeads)
+
+    ret := make(map[string][]*dispatchJob)
+    for _, m := range members {
+        for _, a := range memberAppointments (m, appts) {
+            if moving[a.id] || a.end.After (shiftStart) == false || a.start.Before (shiftEnd) == false { continue }
+
+            // whatever hangs off the ends of the shift doesn't matter, just that they're busy inside it
+            b := &dispatchJob { job: jobs[a.id], lead: leads[a.id], booked: true, location: a.location, earliest: a.start, latest: a.end }
+            if b.earliest.Before (shiftStart) { b.earliest = shiftStart }
+            if b.latest.After (shiftEnd) { b.latest = shiftEnd }
+            b.duration = b.latest.Sub (b.earliest)
+
+            ret[m.Id] = append (ret[m.Id], b)
+        }
+    }
+    return ret
+}
+
+func (this DispatchOptions) depot (m *Member) Point {
+    if pt, ok := this.Depots[m.Id]; ok { return pt }
+    return this.Depot
+}
+
+// scheduled jobs have to be done inside their window, the rest can go anywhere in the shift
+func (this DispatchOptions) toDispatchJob (job *Job, shiftStart, shiftEnd time.Time) *dispatchJob {
+    ret := &dispatchJob { job: job, earliest: shiftStart, latest: shiftEnd, duration: this.JobDuration }
+    ret.location, _ = job.Location()
+
+    if job.JobDateTime.IsZero() {
+        if ret.duration <= 0 { ret.duration = defaultJobDuration }
+        return ret
+    }
+
+    ret.earliest, ret.latest = job.Window()
+    if window := ret.latest.Sub (ret.earliest); ret.duration <= 0 || ret.duration > window { ret.duration = window }
+    return ret
+}
+
+// true if the member can do this kind of job at all
+func dispatchEligible (m *Member, job *Job) bool {
+    if m.Active == false || m.FieldTech == false { return false }
+    return len(job.JobType) == 0 || m.HasSkill (job.JobType)
+}
+
+// walks a tech's day in order, working out the times at each stop
+// returns false if any of it doesn't fit
+func (this DispatchOptions) simulate (depot Point, jobs []*dispatchJob, shiftStart, shiftEnd time.Time) ([]*DispatchStop, float64, bool) {
+    ret := make([]*DispatchStop, 0, len(jobs))
+    total := 0.0
+
+    at, pos := shiftStart, depot
+    for _, j := range jobs {
+        stop := &DispatchStop { Job: j.job, Lead: j.lead, Booked: j.booked, Location: j.location }
+        if pos.Valid() && j.location.Valid() {
+            stop.Distance = DistanceMiles (pos, j.location)
+            stop.Drive = DriveTime (stop.Distance, this.AverageSpeed)
+        }
+
+        stop.Arrive = at.Add (stop.Drive)
+        stop.Start = stop.Arrive
+        if stop.Start.Before (j.earliest) { stop.Start = j.earliest } // early, so they wait
+        stop.End = stop.Start.Add (j.duration)
+
+        if stop.End.After (j.latest) || stop.End.After (shiftEnd) { return nil, 0, false }
+
+        ret = append (ret, stop)
+        total += stop.Distance
+        at = stop.End
+        if j.location.Valid() { pos = j.location } // booked work without coordinates, we carry on from the last place we knew
+    }
+
+    if this.ReturnToDepot && depot.Valid() && len(jobs) > 0 {
+        back := DistanceMiles (pos, depot)
+        if at.Add (DriveTime (back, this.AverageSpeed)).After (shiftEnd) { return nil, 0, false }
+        total += back
+    }
+    return ret, total, true
+}
+
+// fills in the route's totals from its stops
+func (this DispatchOptions) finishRoute (route *DispatchRoute) {
+    route.Distance, route.Drive = 0, 0
+    for _, s := range route.Stops {
+        route.Distance += s.Distance
+        route.Drive += s.Drive
+    }
+
+    if this.ReturnToDepot && route.Depot.Valid() && len(route.Stops) > 0 {
+        back := DistanceMiles (route.Stops[len(route.Stops)-1].Location, route.Depot)
+        route.Distance += back
+        route.Drive += DriveTime (back, this.AverageSpeed)
+    }
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// spreads the jobs across the members using cheapest insertion
+// the jobs with the tightest windows are placed first, each one goes wherever it adds the fewest miles
+// without breaking anyone's shift or another job's window. it's a heuristic, so it's good, not perfect
+// only active field techs with the job's skill are considered. nothing is sent to workiz, see ApplyDispatch
+// anything in opts.Booked stays where it is, and new work only goes in the gaps around it
+func Dispatch (jobs []*Job, members Members, opts DispatchOptions) *DispatchPlan {
+    plan := &DispatchPlan { team: members }
+    shiftStart, shiftEnd := opts.shift()
+
+    moving := make(map[string]bool, len(jobs))
+    for _, j := range jobs { moving[j.UUID] = true }
+    booked := opts.booked (members, moving, shiftStart, shiftEnd)
+
+    var work []*dispatchJob
+    for _, j := range jobs {
+        if _, ok := j.Location(); ok == false {
+            plan.Skipped = append (plan.Skipped, j)
+            continue
+        }
+        work = append (work, opts.toDispatchJob (j, shiftStart, shiftEnd))
+    }
+
+    sort.SliceStable (work, func (i, j int) bool {
+        wi, wj := work[i].latest.Sub (work[i].earliest), work[j].latest.Sub (work[j].earliest)
+        if wi != wj { return wi < wj }
+        return work[i].earliest.Before (work[j].earliest)
+    })
+
+    // what each tech has so far, lined up with plan.Routes, starting with what they're already booked on
+    assigned := make([][]*dispatchJob, 0, len(members))
+    costs := make([]float64, 0, len(members))
+    full := make([]bool, 0, len(members))
+    for _, m := range members {
+        if m.Active == false || m.FieldTech == false { continue }
+
+        route := &DispatchRoute { Member: m, Depot: opts.depot (m) }
+        stops, cost, ok := opts.simulate (route.Depot, booked[m.Id], shiftStart, shiftEnd)
+        if ok {
+            route.Stops = stops
+        } else {
+            // their day doesn't work as it is, so we leave it alone and don't give them anything else
+            for _, b := range booked[m.Id] {
+                route.Stops = append (route.Stops, &DispatchStop { Job: b.job, Lead: b.lead, Booked: true, Location: b.location, Arrive: b.earliest, Start: b.earliest, End: b.latest })
+            }
+        }
+
+        plan.Routes = append (plan.Routes, route)
+        assigned = append (assigned, booked[m.Id])
+        costs = append (costs, cost)
+        full = append (full, ok == false)
+    }
+
+    for _, j := range work {
+        bestRoute, bestPos := -1, 0
+        var bestCost float64
+        var bestStops []*DispatchStop
+
+        for r, route := range plan.Routes {
+            if full[r] || dispatchEligible (route.Member, j.job) == false { continue }
+            if opts.MaxJobs > 0 && len(assigned[r]) >= opts.MaxJobs { continue }
+
+            depot := opts.depot (route.Member)
+            for pos := 0; pos <= len(assigned[r]); pos++ {
+                trial := make([]*dispatchJob, 0, len(assigned[r]) + 1)
+                trial = append (append (append (trial, assigned[r][:pos]...), j), assigned[r][pos:]...)
+
+                stops, cost, ok := opts.simulate (depot, trial, shiftStart, shiftEnd)
+                if ok == false { continue }
+
+                added := cost - costs[r]
+                better := bestRoute < 0 || added < bestCost - 1e-9
+                if better == false && added < bestCost + 1e-9 && len(assigned[r]) < len(assigned[bestRoute]) { better = true } // a tie goes to the lighter day
+                if better {
+                    bestRoute, bestPos, bestCost, bestStops = r, pos, added, stops
+                }
+            }
+        }
+
+        if bestRoute < 0 {
+            plan.Unassigned = append (plan.Unassigned, j.job)
+            continue
+        }
+
+        r := assigned[bestRoute]
+        assigned[bestRoute] = append (r[:bestPos], append ([]*dispatchJob{ j }, r[bestPos:]...)...)
+        costs[bestRoute] += bestCost
+        plan.Routes[bestRoute].Stops = bestStops
+    }
+
+    for _, route := range plan.Routes {
+        opts.finishRoute (route)
+        for _, s := range route.Stops {
+            if s.Booked { continue } // already in workiz
+            plan.Calls = append (plan.Calls,
+                &DispatchCall { Kind: DispatchCall_schedule, JobId: s.Job.UUID, Start: s.Start, Duration: s.End.Sub (s.Start) },
+                &DispatchCall { Kind: DispatchCall_crew, JobId: s.Job.UUID, Crew: []string{ route.Member.Name } })
+        }
+    }
+    return plan
+}
+
+// makes the calls in the plan, jobs are sent concurrently but each job's calls go in order
+// so a job never gets a crew if its schedule didn't work
+// failures come back as BatchErrors keyed by job id
+func (this *Workiz) ApplyDispatch (ctx context.Context, token, secret string, plan *DispatchPlan, concurrency int) error {
+    var ids []string
+    byJob := make(map[string][]*DispatchCall)
+    for _, c := range plan.Calls {
+        if _, ok := byJob[c.JobId]; ok == false { ids = append (ids, c.JobId) }
+        byJob[c.JobId] = append (byJob[c.JobId], c)
+    }
+
+    _, bad := fanOut (ctx, ids, concurrency, func (ctx context.Context, id string) (bool, error) {
+        for _, c := range byJob[id] {
+            var err error
+            switch c.Kind {
+            case DispatchCall_schedule:
+                err = this.UpdateJobSchedule (ctx, token, secret, id, c.Start.UTC(), c.Duration)
+            case DispatchCall_crew:
+                err = this.UpdateJobCrew (ctx, token, secret, id, plan.team, c.Crew)
+            default:
+                err = errors.Wrapf (ErrUnexpected, "unknown dispatch call %s", c.Kind)
+            }
+            if err != nil { return false, errors.Wrap (err, string(c.Kind)) }
+        }
+        return true, nil
+    })
+    return bad.err()
+}
@@ -0,0 +1,147 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDispatch (t *testing.T) {
+	anyone := func (j *Job) *Job {
+		j.JobType = "" // anyone can do these
+		return j
+	}
+
+	jobs := []*Job {
+		anyone (testJob (t, "AAA111", "", 0, 44.41, -73.20)),
+		anyone (testJob (t, "BBB222", "", 0, 44.42, -73.20)),
+		anyone (testJob (t, "CCC333", "", 0, 44.43, -73.20)),
+		testJob (t, "DDD444", "2023-02-28 10:00", 60, 44.40, -73.21), // growler fill at 10, only 1 can do it
+		testJob (t, "EEE555", "2023-02-28 20:00", 60, 44.40, -73.21), // after everyone's shift
+		testJob (t, "FFF666", "", 0, 0, 0), // nowhere
+	}
+
+	opts := DispatchOptions {
+		Day: time.Date (2023, 2, 28, 0, 0, 0, 0, time.UTC),
+		Depot: Point { Lat: 44.40, Lng: -73.20 },
+		AverageSpeed: 30,
+		MaxJobs: 2,
+	}
+
+	plan := Dispatch (jobs, testMembers(), opts)
+	assert.Equal (t, 3, len(plan.Routes)) // 4 is office staff
+
+	if assert.Equal (t, 1, len(plan.Skipped)) {
+		assert.Equal (t, "FFF666", plan.Skipped[0].UUID)
+	}
+	if assert.Equal (t, 1, len(plan.Unassigned)) {
+		assert.Equal (t, "EEE555", plan.Unassigned[0].UUID)
+	}
+
+	placed := 0
+	for _, r := range plan.Routes {
+		assert.Equal (t, true, len(r.Stops) <= 2, r.Member.Name)
+		placed += len(r.Stops)
+
+		for i, s := range r.Stops {
+			assert.Equal (t, false, s.Start.Before (s.Arrive))
+			assert.Equal (t, false, s.End.After (time.Date (2023, 2, 28, 17, 0, 0, 0, time.UTC)))
+			if i > 0 { assert.Equal (t, false, s.Arrive.Before (r.Stops[i-1].End)) }
+
+			if s.Job.UUID == "DDD444" {
+				assert.Equal (t, "1", r.Member.Id)
+				assert.Equal (t, 10, s.Start.Hour())
+				assert.Equal (t, time.Hour, s.End.Sub (s.Start))
+			}
+		}
+	}
+	assert.Equal (t, 4, placed)
+	assert.Equal (t, 8, len(plan.Calls))
+
+	// each job gets scheduled, then crewed
+	if assert.Equal (t, true, len(plan.Calls) > 1) {
+		assert.Equal (t, DispatchCall_schedule, plan.Calls[0].Kind)
+		assert.Equal (t, DispatchCall_crew, plan.Calls[1].Kind)
+		assert.Equal (t, plan.Calls[0].JobId, plan.Calls[1].JobId)
+		assert.Equal (t, 1, len(plan.Calls[1].Crew))
+	}
+
+	// a single tech with room for everything gets them in a sensible order, closest first
+	opts.MaxJobs = 0
+	plan = Dispatch (jobs[:3], testMembers()[:1], opts)
+	if assert.Equal (t, 1, len(plan.Routes)) && assert.Equal (t, 3, len(plan.Routes[0].Stops)) {
+		assert.Equal (t, "AAA111", plan.Routes[0].Stops[0].Job.UUID)
+		assert.Equal (t, "BBB222", plan.Routes[0].Stops[1].Job.UUID)
+		assert.Equal (t, "CCC333", plan.Routes[0].Stops[2].Job.UUID)
+		assert.Equal (t, 8, plan.Routes[0].Stops[0].Arrive.Hour())
+	}
+}
+
+func TestDispatchBooked (t *testing.T) {
+	team := testMembers()
+	members := Members { team[0], team[2] } // 1 has the growler fill skill, 3 doesn't
+
+	opts := DispatchOptions {
+		Day: time.Date (2023, 2, 28, 0, 0, 0, 0, time.UTC),
+		Depot: Point { Lat: 44.40, Lng: -73.20 },
+		AverageSpeed: 30,
+		Booked: []*Job { testJob (t, "BOOK01", "2023-02-28 09:00", 180, 44.40, -73.20, "1") }, // 1 is busy 9 to 12
+	}
+
+	// only 1 could do it, and they're already booked at 9
+	plan := Dispatch ([]*Job { testJob (t, "DDD444", "2023-02-28 09:00", 60, 44.40, -73.21) }, members, opts)
+	if assert.Equal (t, 1, len(plan.Unassigned)) {
+		assert.Equal (t, "DDD444", plan.Unassigned[0].UUID)
+	}
+	assert.Equal (t, 0, len(plan.Calls))
+
+	// their booking is still on the route, so the day reads right, but nothing gets sent for it
+	if assert.Equal (t, 1, len(plan.Routes[0].Stops)) {
+		s := plan.Routes[0].Stops[0]
+		assert.Equal (t, true, s.Booked)
+		assert.Equal (t, "BOOK01", s.Job.UUID)
+		assert.Equal (t, 9, s.Start.Hour())
+	}
+
+	// at 1 in the afternoon it fits after their booking
+	plan = Dispatch ([]*Job { testJob (t, "DDD444", "2023-02-28 13:00", 60, 44.40, -73.21) }, members, opts)
+	assert.Equal (t, 0, len(plan.Unassigned))
+	if assert.Equal (t, 2, len(plan.Routes[0].Stops)) {
+		assert.Equal (t, "DDD444", plan.Routes[0].Stops[1].Job.UUID)
+		assert.Equal (t, false, plan.Routes[0].Stops[1].Booked)
+	}
+	assert.Equal (t, 2, len(plan.Calls))
+
+	// booked work counts toward the limit, leads too
+	lead := &Lead{}
+	err := json.Unmarshal ([]byte(`{"UUID":"LEAD01","LeadDateTime":"2023-02-28 15:00:00","LeadEndDateTime":"2023-02-28 16:00:00",
+		"Latitude":"44.41","Longitude":"-73.20","Team":[{"id":3,"name":"tech 3"}]}`), lead)
+	if err != nil { t.Fatal (err) }
+
+	opts.BookedLeads = []*Lead { lead }
+	opts.MaxJobs = 1
+	job := testJob (t, "AAA111", "", 0, 44.41, -73.20)
+	job.JobType = "" // anyone can do it
+
+	plan = Dispatch ([]*Job { job }, members, opts)
+	if assert.Equal (t, 1, len(plan.Unassigned)) {
+		assert.Equal (t, "AAA111", plan.Unassigned[0].UUID)
+	}
+	if assert.Equal (t, 1, len(plan.Routes[1].Stops)) {
+		assert.Equal (t, "LEAD01", plan.Routes[1].Stops[0].Lead.UUID)
+		assert.Nil (t, plan.Routes[1].Stops[0].Job)
+	}
+
+	// the map doesn't choke on the booked lead
+	fc := DispatchGeoJSON (plan)
+	assert.Equal (t, true, len(fc.Features) > 0)
+
+	// a job being dispatched that's also in the bookings is getting moved, so it doesn't block anyone
+	opts.MaxJobs = 0
+	opts.BookedLeads = nil
+	plan = Dispatch ([]*Job { testJob (t, "BOOK01", "2023-02-28 09:00", 60, 44.40, -73.21) }, members, opts)
+	assert.Equal (t, 0, len(plan.Unassigned))
+	assert.Equal (t, 2, len(plan.Calls))
+}
@@ -0,0 +1,176 @@
+/** ****************************************************************************************************************** **
+	Tolerant decoders
+	Workiz returns the same field as a string, an int, a float or a bool depending on how the user entered it
+	these accept all of them, and keep a warning around when they really can't make sense of the value
+	they're only used where workiz really does mix them up, like ids and coordinates that are numbers on jobs and strings on leads
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "fmt"
+    "math"
+    "reflect"
+    "strconv"
+    "strings"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type flexible interface {
+    ~string | ~int64 | ~float64 | ~bool
+}
+
+// a value that can be decoded from any of the json encodings workiz likes to use
+// if the value couldn't be converted, Value is left as the zero value and Warning says why
+type Flex[T flexible] struct {
+    Value T
+    Warning string
+}
+
+type FlexString = Flex[string]
+type FlexInt = Flex[int64]
+type FlexFloat = Flex[float64]
+type FlexBool = Flex[bool]
+
+func (this *Flex[T]) UnmarshalJSON (b []byte) error {
+    var zero T
+    this.Value = zero
+    this.Warning = ""
+
+    raw := strings.TrimSpace (string(b))
+    if raw == "null" { return nil } // leave it empty
+
+    // strings come in quoted, everything else doesn't
+    s := raw
+    if strings.HasPrefix (raw, "\"") {
+        unq, err := strconv.Unquote (raw)
+        if err != nil {
+            this.Warning = fmt.Sprintf ("bad string %s : %s", raw, err.Error())
+            return nil
+        }
+        s = strings.TrimSpace (unq)
+    }
+
+    switch v := any(&this.Value).(type) {
+    case *string:
+        if strings.HasPrefix (raw, "{") || strings.HasPrefix (raw, "[") {
+            this.Warning = fmt.Sprintf ("expected a string, got %s", raw)
+            return nil
+        }
+        if strings.HasPrefix (raw, "\"") { // keep any spaces they had inside the quotes
+            s, _ = strconv.Unquote (raw)
+        }
+        *v = s
+
+    case *int64:
+        if len(s) == 0 { return nil } // empty string is just zero
+        i, err := strconv.ParseInt (s, 10, 64)
+        if err != nil {
+            // could be "12.0", which is fine as long as there's nothing after the decimal
+            f, fErr := strconv.ParseFloat (s, 64)
+            if fErr != nil || f != float64(int64(f)) {
+                this.Warning = fmt.Sprintf ("expected an int, got %s", raw)
+                return nil
+            }
+            i = int64(f)
+        }
+        *v = i
+
+    case *float64:
+        if len(s) == 0 { return nil }
+        f, err := strconv.ParseFloat (s, 64)
+        if err != nil || math.IsNaN (f) || math.IsInf (f, 0) { // json can't hold NaN or Inf, so we'd never be able to send it back
+            this.Warning = fmt.Sprintf ("expected a float, got %s", raw)
+            return nil
+        }
+        *v = f
+
+    case *bool:
+        switch strings.ToLower (s) {
+        case "", "0", "false", "no", "n", "off":
+            *v = false
+        case "1", "true", "yes", "y", "on":
+            *v = true
+        default:
+            this.Warning = fmt.Sprintf ("expected a bool, got %s", raw)
+        }
+    }
+    return nil // we never fail the whole record for one field
+}
+
+func (this Flex[T]) MarshalJSON () ([]byte, error) {
+    switch v := any(this.Value).(type) {
+    case string:
+        return []byte(strconv.Quote (v)), nil
+    case int64:
+        return []byte(strconv.FormatInt (v, 10)), nil
+    case float64:
+        return []byte(strconv.FormatFloat (v, 'f', -1, 64)), nil
+    case bool:
+        return []byte(strconv.FormatBool (v)), nil
+    }
+    return []byte("null"), nil // can't happen with our type constraint
+}
+
+func (this Flex[T]) String () string {
+    return fmt.Sprintf ("%v", this.Value)
+}
+
+// lets DecodeWarnings find these without knowing the type parameter
+func (this Flex[T]) decodeWarning () string {
+    return this.Warning
+}
+
+type flexWarner interface {
+    decodeWarning () string
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// walks a decoded model and returns any warnings from its flex fields, prefixed with the field name
+// handy for logging after a GetJob or ListLeads so we know what workiz sent that we couldn't read
+func DecodeWarnings (model interface{}) (ret []string) {
+    val := reflect.ValueOf (model)
+    for val.Kind() == reflect.Ptr {
+        if val.IsNil() { return }
+        val = val.Elem()
+    }
+    collectWarnings (val, "", &ret)
+    return
+}
+
+func collectWarnings (val reflect.Value, path string, ret *[]string) {
+    if val.CanInterface() {
+        if w, ok := val.Interface().(flexWarner); ok {
+            if msg := w.decodeWarning(); len(msg) > 0 {
+                *ret = append (*ret, fmt.Sprintf ("%s : %s", path, msg))
+            }
+            return
+        }
+    }
+
+    switch val.Kind() {
+    case reflect.Ptr:
+        if val.IsNil() == false { collectWarnings (val.Elem(), path, ret) }
+
+    case reflect.Struct:
+        typ := val.Type()
+        for i := 0; i < val.NumField(); i++ {
+            if typ.Field(i).PkgPath != "" { continue } // unexported
+            name := typ.Field(i).Name
+            if len(path) > 0 { name = path + "." + name }
+            collectWarnings (val.Field(i), name, ret)
+        }
+
+    case reflect.Slice, reflect.Array:
+        for i := 0; i < val.Len(); i++ {
+            collectWarnings (val.Index(i), fmt.Sprintf ("%s[%d]", path, i), ret)
+        }
+    }
+}
@@ -0,0 +1,68 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"testing"
+	"encoding/json"
+)
+
+func TestFlexDecoders (t *testing.T) {
+	var data struct {
+		A, B, C FlexInt
+		D, E FlexFloat
+		F, G, H FlexBool
+		I, J FlexString
+	}
+
+	err := json.Unmarshal ([]byte(`{"A":"12","B":7,"C":"12.0","D":"-73.2037722","E":44,"F":"1","G":true,"H":"false","I":123,"J":" 5B "}`), &data)
+	if err != nil { t.Fatal(err) }
+
+	assert.Equal (t, int64(12), data.A.Value)
+	assert.Equal (t, int64(7), data.B.Value)
+	assert.Equal (t, int64(12), data.C.Value)
+	assert.Equal (t, -73.2037722, data.D.Value)
+	assert.Equal (t, 44.0, data.E.Value)
+	assert.Equal (t, true, data.F.Value)
+	assert.Equal (t, true, data.G.Value)
+	assert.Equal (t, false, data.H.Value)
+	assert.Equal (t, "123", data.I.Value)
+	assert.Equal (t, " 5B ", data.J.Value)
+	assert.Equal (t, 0, len(DecodeWarnings (&data)))
+
+	b, err := json.Marshal (data.D)
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, "-73.2037722", string(b))
+}
+
+func TestFlexWarnings (t *testing.T) {
+	job := &Job{}
+
+	// bad values shouldn't fail the whole job, but we want to know about them
+	err := json.Unmarshal ([]byte(`{"UUID":"OWX12J","SerialId":"abc","Latitude":{"lat":1},"Unit":12}`), job)
+	if err != nil { t.Fatal(err) }
+
+	assert.Equal (t, "OWX12J", job.UUID)
+	assert.Equal (t, "12", job.Unit.Value)
+	assert.Equal (t, int64(0), job.SerialId.Value)
+
+	warnings := DecodeWarnings (job)
+	assert.Equal (t, 2, len(warnings))
+	assert.Contains (t, warnings[0], "SerialId")
+	assert.Contains (t, warnings[1], "Latitude")
+}
+
+func TestFlexNotANumber (t *testing.T) {
+	for _, raw := range []string { `"NaN"`, `"Inf"`, `"+Inf"`, `"-infinity"` } {
+		var f FlexFloat
+		if err := json.Unmarshal ([]byte(raw), &f); err != nil { t.Fatal(err) }
+
+		assert.Equal (t, 0.0, f.Value, raw)
+		assert.NotEmpty (t, f.Warning, raw)
+
+		// and it can still go back out
+		b, err := json.Marshal (f)
+		assert.NoError (t, err, raw)
+		assert.Equal (t, "0", string(b))
+	}
+}
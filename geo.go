@@ -0,0 +1,68 @@
+/** ****************************************************************************************************************** **
+	Basic geography
+	Points, distances and getting coordinates out of jobs and leads
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "math"
+    "time"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+const earthRadiusMiles = 3958.8
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type Point struct {
+    Lat, Lng float64
+}
+
+// workiz uses 0,0 (or empty) for "we don't know", which is in the ocean anyway
+func (this Point) Valid () bool {
+    if this.Lat == 0 && this.Lng == 0 { return false }
+    return this.Lat >= -90 && this.Lat <= 90 && this.Lng >= -180 && this.Lng <= 180
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// straight line distance over the earth between 2 points, in miles
+func DistanceMiles (a, b Point) float64 {
+    toRad := func (deg float64) float64 { return deg * math.Pi / 180 }
+
+    dLat := toRad (b.Lat - a.Lat)
+    dLng := toRad (b.Lng - a.Lng)
+
+    h := math.Sin (dLat / 2) * math.Sin (dLat / 2) +
+        math.Cos (toRad (a.Lat)) * math.Cos (toRad (b.Lat)) * math.Sin (dLng / 2) * math.Sin (dLng / 2)
+
+    return 2 * earthRadiusMiles * math.Asin (math.Min (1, math.Sqrt (h)))
+}
+
+// the job's coordinates, false if workiz doesn't have them
+func (this *Job) Location () (Point, bool) {
+    pt := Point { Lat: this.Latitude.Value, Lng: this.Longitude.Value }
+    return pt, pt.Valid()
+}
+
+// the lead's coordinates, false if workiz doesn't have them
+func (this *Lead) Location () (Point, bool) {
+    pt := Point { Lat: this.Latitude.Value, Lng: this.Longitude.Value }
+    return pt, pt.Valid()
+}
+
+// how long it takes to drive this many miles at an average speed in mph
+// a speed of 0 or less means we're not estimating, so it's always 0
+func DriveTime (miles, mph float64) time.Duration {
+    if mph <= 0 || miles <= 0 { return 0 }
+    return time.Duration (miles / mph * float64(time.Hour))
+}
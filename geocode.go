@@ -0,0 +1,226 @@
+/** ****************************************************************************************************************** **
+	Geocoding
+	Workiz doesn't always have coordinates for jobs and leads, so this fills them in from the address
+	using whatever geocoder the caller plugs in, and can check an address is real before we create anything
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "github.com/pkg/errors"
+
+    "context"
+    "encoding/json"
+    "os"
+    "strings"
+    "sync"
+    "unicode"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+var (
+    ErrAddressNotFound  = errors.New("Address not found")
+    ErrInvalidAddress   = errors.New("Invalid address")
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// looks up coordinates for an address
+// return ErrAddressNotFound (wrapped is fine) when the address doesn't exist, anything else is treated as the lookup failing
+type Geocoder interface {
+    Geocode (ctx context.Context, addr Address) (Point, error)
+}
+
+type Address struct {
+    Address, City, State, PostalCode, Country string
+}
+
+// the way you'd write it on an envelope, skipping anything that's empty
+func (this Address) String () string {
+    var parts []string
+    for _, p := range []string { this.Address, this.City, strings.TrimSpace (this.State + " " + this.PostalCode), this.Country } {
+        if p = strings.TrimSpace (p); len(p) > 0 { parts = append (parts, p) }
+    }
+    return strings.Join (parts, ", ")
+}
+
+// true if there's enough here to look up
+func (this Address) Empty () bool {
+    return len(strings.TrimSpace (this.Address)) == 0
+}
+
+// the address as a lookup key, lowercase with the punctuation and extra spaces taken out
+// so "123 Main St., Burlington" and "123 main st burlington" are the same
+// the country is left out, workiz only fills it in on some records and "US" vs "USA" vs nothing shouldn't matter
+func (this Address) key () string {
+    this.Country = ""
+    fields := strings.FieldsFunc (strings.ToLower (this.String()), func (r rune) bool {
+        return unicode.IsLetter (r) == false && unicode.IsNumber (r) == false
+    })
+    return strings.Join (fields, " ")
+}
+
+// an address to coordinates table in a json file, keyed by the full address
+//  { "123 Main St, Burlington, VT 05401": { "Lat": 44.47, "Lng": -73.21 } }
+// safe to share between goroutines
+type FileGeocoder struct {
+    path string
+    lock sync.RWMutex
+    table map[string]Point
+}
+
+// an address we've already looked up, or couldn't find
+type geocodeResult struct {
+    pt Point
+    err error
+}
+
+// remembers what the geocoder it wraps says, including addresses it couldn't find
+// other errors aren't remembered so they get tried again. safe to share between goroutines
+type CachedGeocoder struct {
+    Geocoder Geocoder
+
+    lock sync.RWMutex
+    cache map[string]geocodeResult
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+func (this *Job) address () Address {
+    return Address { Address: this.Address, City: this.City, State: this.State, PostalCode: this.PostalCode, Country: this.Country }
+}
+
+func (this *Lead) address () Address {
+    return Address { Address: this.Address, City: this.City, State: this.State, PostalCode: this.PostalCode, Country: this.Country }
+}
+
+// where a new job or lead is going to be, from the geocoder when we have one
+// makes sure the address is something it can find before we create anything with it
+// only an address it couldn't find stops us, if the geocoder itself is down that's not the address's fault
+// so we carry on without a point
+func (this *Workiz) locate (ctx context.Context, addr Address) (Point, error) {
+    if this.Geocoder == nil { return Point{}, nil }
+    if addr.Empty() { return Point{}, errors.Wrap (ErrInvalidAddress, "missing address") }
+
+    pt, err := this.Geocoder.Geocode (ctx, addr)
+    if errors.Is (err, ErrAddressNotFound) { return Point{}, errors.Wrap (ErrInvalidAddress, addr.String()) }
+    if err != nil { return Point{}, nil }
+    return pt, nil
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// loads the table from the file, a file that doesn't exist yet is just an empty table
+func NewFileGeocoder (path string) (*FileGeocoder, error) {
+    ret := &FileGeocoder { path: path, table: make(map[string]Point) }
+
+    data, err := os.ReadFile (path)
+    if os.IsNotExist (err) { return ret, nil }
+    if err != nil { return nil, errors.WithStack (err) }
+
+    var raw map[string]Point
+    err = json.Unmarshal (data, &raw)
+    if err != nil { return nil, errors.Wrap (err, path) }
+
+    for addr, pt := range raw {
+        ret.table[Address { Address: addr }.key()] = pt
+    }
+    return ret, nil
+}
+
+func (this *FileGeocoder) Geocode (ctx context.Context, addr Address) (Point, error) {
+    this.lock.RLock()
+    defer this.lock.RUnlock()
+
+    pt, ok := this.table[addr.key()]
+    if ok == false { return Point{}, errors.Wrap (ErrAddressNotFound, addr.String()) }
+    return pt, nil
+}
+
+// adds or replaces an address in the table, call Save to write it out
+func (this *FileGeocoder) Set (addr Address, pt Point) {
+    this.lock.Lock()
+    defer this.lock.Unlock()
+    this.table[addr.key()] = pt
+}
+
+// writes the table back to the file, keyed by the cleaned up addresses
+func (this *FileGeocoder) Save () error {
+    this.lock.RLock()
+    data, err := json.MarshalIndent (this.table, "", "  ")
+    this.lock.RUnlock()
+    if err != nil { return errors.WithStack (err) }
+
+    return errors.WithStack (os.WriteFile (this.path, data, 0644))
+}
+
+func NewCachedGeocoder (g Geocoder) *CachedGeocoder {
+    return &CachedGeocoder { Geocoder: g, cache: make(map[string]geocodeResult) }
+}
+
+func (this *CachedGeocoder) Geocode (ctx context.Context, addr Address) (Point, error) {
+    key := addr.key()
+
+    this.lock.RLock()
+    res, ok := this.cache[key]
+    this.lock.RUnlock()
+    if ok { return res.pt, res.err }
+
+    pt, err := this.Geocoder.Geocode (ctx, addr)
+    if err == nil || errors.Is (err, ErrAddressNotFound) {
+        this.lock.Lock()
+        this.cache[key] = geocodeResult { pt: pt, err: err }
+        this.lock.Unlock()
+    }
+    return pt, err
+}
+
+// forgets everything, so the next lookups go to the wrapped geocoder
+func (this *CachedGeocoder) Clear () {
+    this.lock.Lock()
+    defer this.lock.Unlock()
+    this.cache = make(map[string]geocodeResult)
+}
+
+// fills in the coordinates for any jobs that don't have them
+// everything is attempted, jobs we couldn't place come back as BatchErrors keyed by job id
+func GeocodeJobs (ctx context.Context, g Geocoder, jobs []*Job) error {
+    var bad BatchErrors
+    for _, j := range jobs {
+        if _, ok := j.Location(); ok { continue } // already have it
+
+        pt, err := g.Geocode (ctx, j.address())
+        if err != nil {
+            bad = append (bad, &BatchError { Id: j.UUID, Err: err })
+            continue
+        }
+        j.Latitude.Value, j.Longitude.Value = pt.Lat, pt.Lng
+    }
+    return bad.err()
+}
+
+// same as GeocodeJobs, for leads
+func GeocodeLeads (ctx context.Context, g Geocoder, leads []*Lead) error {
+    var bad BatchErrors
+    for _, l := range leads {
+        if _, ok := l.Location(); ok { continue }
+
+        pt, err := g.Geocode (ctx, l.address())
+        if err != nil {
+            bad = append (bad, &BatchError { Id: l.UUID, Err: err })
+            continue
+        }
+        l.Latitude.Value, l.Longitude.Value = pt.Lat, pt.Lng
+    }
+    return bad.err()
+}
@@ -0,0 +1,143 @@
+package workiz
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// counts how many times it gets asked, and fails on anything it doesn't know
+type countingGeocoder struct {
+	calls int
+	known map[string]Point
+	down bool
+}
+
+func (this *countingGeocoder) Geocode (ctx context.Context, addr Address) (Point, error) {
+	this.calls++
+	if this.down { return Point{}, errors.New ("service unavailable") }
+
+	pt, ok := this.known[addr.Address]
+	if ok == false { return Point{}, errors.Wrap (ErrAddressNotFound, addr.String()) }
+	return pt, nil
+}
+
+func TestFileGeocoder (t *testing.T) {
+	path := filepath.Join (t.TempDir(), "geo.json")
+	err := os.WriteFile (path, []byte(`{"123 Main St, Burlington, VT 05401": {"Lat": 44.47, "Lng": -73.21}}`), 0644)
+	if err != nil { t.Fatal (err) }
+
+	g, err := NewFileGeocoder (path)
+	if err != nil { t.Fatal (err) }
+
+	ctx := context.Background()
+
+	// punctuation and case don't matter
+	pt, err := g.Geocode (ctx, Address { Address: "123 main st.", City: "Burlington", State: "vt", PostalCode: "05401" })
+	assert.NoError (t, err)
+	assert.Equal (t, Point { Lat: 44.47, Lng: -73.21 }, pt)
+
+	_, err = g.Geocode (ctx, Address { Address: "1 Nowhere Rd", City: "Burlington" })
+	assert.Equal (t, ErrAddressNotFound, errors.Cause (err))
+
+	// jobs from workiz come with a country, the file and new jobs usually don't
+	job := testJob (t, "AAA111", "", 0, 0, 0)
+	job.Address, job.City, job.State, job.PostalCode, job.Country = "123 Main St", "Burlington", "VT", "05401", "US"
+	assert.NoError (t, GeocodeJobs (ctx, g, []*Job { job }))
+	assert.Equal (t, 44.47, job.Latitude.Value)
+
+	// new ones survive a save and reload
+	g.Set (Address { Address: "5 State St", City: "Montpelier", State: "VT" }, Point { Lat: 44.26, Lng: -72.57 })
+	assert.NoError (t, g.Save())
+
+	g, err = NewFileGeocoder (path)
+	if err != nil { t.Fatal (err) }
+	pt, err = g.Geocode (ctx, Address { Address: "5 State St", City: "Montpelier", State: "VT" })
+	assert.NoError (t, err)
+	assert.Equal (t, 44.26, pt.Lat)
+
+	// no file yet is fine
+	g, err = NewFileGeocoder (filepath.Join (t.TempDir(), "missing.json"))
+	assert.NoError (t, err)
+	assert.NotNil (t, g)
+}
+
+func TestCachedGeocoder (t *testing.T) {
+	inner := &countingGeocoder { known: map[string]Point { "123 Main St": { Lat: 44.47, Lng: -73.21 } } }
+	g := NewCachedGeocoder (inner)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		pt, err := g.Geocode (ctx, Address { Address: "123 Main St", City: "Burlington" })
+		assert.NoError (t, err)
+		assert.Equal (t, 44.47, pt.Lat)
+
+		_, err = g.Geocode (ctx, Address { Address: "1 Nowhere Rd" })
+		assert.Equal (t, ErrAddressNotFound, errors.Cause (err))
+	}
+	assert.Equal (t, 2, inner.calls) // hits and misses are both remembered
+
+	// outages aren't
+	inner.down = true
+	g.Geocode (ctx, Address { Address: "9 Other St" })
+	g.Geocode (ctx, Address { Address: "9 Other St" })
+	assert.Equal (t, 4, inner.calls)
+
+	g.Clear()
+	g.Geocode (ctx, Address { Address: "123 Main St", City: "Burlington" })
+	assert.Equal (t, 5, inner.calls)
+}
+
+func TestGeocodeJobs (t *testing.T) {
+	jobs := []*Job {
+		testJob (t, "AAA111", "", 0, 44.1, -73.1),
+		testJob (t, "BBB222", "", 0, 0, 0),
+		testJob (t, "CCC333", "", 0, 0, 0),
+	}
+	jobs[1].Address = "123 Main St"
+	jobs[2].Address = "1 Nowhere Rd"
+
+	inner := &countingGeocoder { known: map[string]Point { "123 Main St": { Lat: 44.47, Lng: -73.21 } } }
+	err := GeocodeJobs (context.Background(), inner, jobs)
+	assert.Equal (t, ErrPartialBatch, errors.Cause (err))
+	assert.Equal (t, 2, inner.calls) // the first one already had coordinates
+
+	assert.Equal (t, 44.1, jobs[0].Latitude.Value)
+	pt, ok := jobs[1].Location()
+	assert.Equal (t, true, ok)
+	assert.Equal (t, -73.21, pt.Lng)
+
+	if bad, ok := err.(BatchErrors); assert.Equal (t, true, ok) {
+		assert.Equal (t, 1, len(bad))
+		assert.Equal (t, ErrAddressNotFound, errors.Cause (bad.For ("CCC333")))
+	}
+}
+
+func TestLocateAddress (t *testing.T) {
+	w := &Workiz{}
+	ctx := context.Background()
+
+	_, err := w.locate (ctx, Address{}) // nothing to check with
+	assert.NoError (t, err)
+
+	w.Geocoder = &countingGeocoder { known: map[string]Point { "123 Main St": { Lat: 44.47, Lng: -73.21 } } }
+	pt, err := w.locate (ctx, Address { Address: "123 Main St" })
+	assert.NoError (t, err)
+	assert.Equal (t, 44.47, pt.Lat)
+
+	_, err = w.locate (ctx, Address { Address: "1 Nowhere Rd" })
+	assert.Equal (t, ErrInvalidAddress, errors.Cause (err))
+
+	_, err = w.locate (ctx, Address{})
+	assert.Equal (t, ErrInvalidAddress, errors.Cause (err))
+
+	// the geocoder being down isn't a reason to refuse the address
+	w.Geocoder = &countingGeocoder { down: true }
+	pt, err = w.locate (ctx, Address { Address: "1 Nowhere Rd" })
+	assert.NoError (t, err)
+	assert.Equal (t, false, pt.Valid())
+}
@@ -0,0 +1,283 @@
+/** ****************************************************************************************************************** **
+	GeoJSON
+	Just enough of the spec (RFC 7946) to read service area polygons, and to put jobs, leads and routes on a map
+	positions are always longitude then latitude
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "github.com/pkg/errors"
+
+    "encoding/json"
+    "time"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+const (
+    GeoJSON_point               = "Point"
+    GeoJSON_lineString          = "LineString"
+    GeoJSON_polygon             = "Polygon"
+    GeoJSON_multiPolygon        = "MultiPolygon"
+    GeoJSON_feature             = "Feature"
+    GeoJSON_featureCollection   = "FeatureCollection"
+)
+
+var ErrInvalidGeoJSON = errors.New("Invalid GeoJSON")
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// the coordinates depend on the type, so they're left raw until we know what we're looking at
+type GeoJSONGeometry struct {
+    Type string `json:"type"`
+    Coordinates json.RawMessage `json:"coordinates"`
+}
+
+type GeoJSONFeature struct {
+    Type string `json:"type"`
+    Geometry *GeoJSONGeometry `json:"geometry"`
+    Properties map[string]interface{} `json:"properties"`
+}
+
+type GeoJSONFeatureCollection struct {
+    Type string `json:"type"`
+    Features []*GeoJSONFeature `json:"features"`
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+func geoJSONPosition (pt Point) []float64 {
+    return []float64 { pt.Lng, pt.Lat }
+}
+
+func geoJSONRing (positions [][]float64) ([]Point, error) {
+    ret := make([]Point, 0, len(positions))
+    for _, p := range positions {
+        if len(p) < 2 { return nil, errors.Wrap (ErrInvalidGeoJSON, "position needs a longitude and latitude") }
+        ret = append (ret, Point { Lat: p[1], Lng: p[0] })
+    }
+    return ret, nil
+}
+
+func geoJSONRings (rings [][][]float64) ([][]Point, error) {
+    ret := make([][]Point, 0, len(rings))
+    for _, r := range rings {
+        ring, err := geoJSONRing (r)
+        if err != nil { return nil, err }
+        ret = append (ret, ring)
+    }
+    return ret, nil
+}
+
+// times are the account's wall clock, same as workiz has them, so they go out without an offset
+// a Z on the end would say they're utc, which they aren't. null when it isn't scheduled
+func geoJSONTime (t time.Time) interface{} {
+    if t.IsZero() { return nil }
+    return t.Format ("2006-01-02T15:04:05")
+}
+
+func jobProperties (j *Job) map[string]interface{} {
+    start, end := j.Window()
+    if j.JobDateTime.IsZero() { start, end = time.Time{}, time.Time{} }
+
+    crew := make([]string, 0, len(j.Team))
+    for _, t := range j.Team { crew = append (crew, t.Name) }
+
+    return map[string]interface{} {
+        "kind": "job",
+        "id": j.UUID,
+        "status": string(j.Status),
+        "sub_status": j.SubStatus,
+        "job_type": j.JobType,
+        "service_area": j.ServiceArea,
+        "crew": crew,
+        "start": geoJSONTime (start),
+        "end": geoJSONTime (end),
+        "address": j.address().String(),
+    }
+}
+
+func leadProperties (l *Lead) map[string]interface{} {
+    start, end := l.Window()
+    if l.LeadDateTime.IsZero() { start, end = time.Time{}, time.Time{} }
+
+    crew := make([]string, 0, len(l.Team))
+    for _, t := range l.Team { crew = append (crew, t.Name) }
+
+    return map[string]interface{} {
+        "kind": "lead",
+        "id": l.UUID,
+        "status": string(l.Status),
+        "sub_status": l.SubStatus,
+        "job_type": firstNonEmpty (l.JobType, l.LeadType),
+        "service_area": l.ServiceArea,
+        "crew": crew,
+        "start": geoJSONTime (start),
+        "end": geoJSONTime (end),
+        "address": l.address().String(),
+    }
+}
+
+// a line from one place to the next on a route
+func geoJSONLeg (from, to Point, leg int, miles float64, drive time.Duration) *GeoJSONFeature {
+    return NewGeoJSONFeature (NewGeoJSONLineString ([]Point { from, to }), map[string]interface{} {
+        "kind": "leg",
+        "leg": leg,
+        "miles": miles,
+        "drive_minutes": drive.Minutes(),
+    })
+}
+
+func geoJSONDepot (pt Point) *GeoJSONFeature {
+    return NewGeoJSONFeature (NewGeoJSONPoint (pt), map[string]interface{} { "kind": "depot" })
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+func NewGeoJSONPoint (pt Point) *GeoJSONGeometry {
+    coords, _ := json.Marshal (geoJSONPosition (pt))
+    return &GeoJSONGeometry { Type: GeoJSON_point, Coordinates: coords }
+}
+
+func NewGeoJSONLineString (pts []Point) *GeoJSONGeometry {
+    line := make([][]float64, 0, len(pts))
+    for _, pt := range pts { line = append (line, geoJSONPosition (pt)) }
+
+    coords, _ := json.Marshal (line)
+    return &GeoJSONGeometry { Type: GeoJSON_lineString, Coordinates: coords }
+}
+
+func NewGeoJSONFeature (geometry *GeoJSONGeometry, properties map[string]interface{}) *GeoJSONFeature {
+    if properties == nil { properties = make(map[string]interface{}) } // the spec wants an object, not null
+    return &GeoJSONFeature { Type: GeoJSON_feature, Geometry: geometry, Properties: properties }
+}
+
+func NewGeoJSONFeatureCollection (features ...*GeoJSONFeature) *GeoJSONFeatureCollection {
+    if features == nil { features = make([]*GeoJSONFeature, 0) }
+    return &GeoJSONFeatureCollection { Type: GeoJSON_featureCollection, Features: features }
+}
+
+// the polygons in a Polygon or MultiPolygon, each is a list of rings
+// the first ring is the outside, any others are holes
+func (this *GeoJSONGeometry) Polygons () ([][][]Point, error) {
+    switch this.Type {
+    case GeoJSON_polygon:
+        var raw [][][]float64
+        if err := json.Unmarshal (this.Coordinates, &raw); err != nil { return nil, errors.Wrap (ErrInvalidGeoJSON, err.Error()) }
+
+        rings, err := geoJSONRings (raw)
+        if err != nil { return nil, err }
+        return [][][]Point { rings }, nil
+
+    case GeoJSON_multiPolygon:
+        var raw [][][][]float64
+        if err := json.Unmarshal (this.Coordinates, &raw); err != nil { return nil, errors.Wrap (ErrInvalidGeoJSON, err.Error()) }
+
+        ret := make([][][]Point, 0, len(raw))
+        for _, poly := range raw {
+            rings, err := geoJSONRings (poly)
+            if err != nil { return nil, err }
+            ret = append (ret, rings)
+        }
+        return ret, nil
+    }
+    return nil, errors.Wrapf (ErrInvalidGeoJSON, "%s isn't a polygon", this.Type)
+}
+
+// the property as a string, empty if it's missing or not a string
+func (this *GeoJSONFeature) StringProperty (name string) string {
+    s, _ := this.Properties[name].(string)
+    return s
+}
+
+// a point for each job, jobs without coordinates are left out
+func JobsGeoJSON (jobs []*Job) *GeoJSONFeatureCollection {
+    ret := NewGeoJSONFeatureCollection()
+    for _, j := range jobs {
+        if pt, ok := j.Location(); ok {
+            ret.Features = append (ret.Features, NewGeoJSONFeature (NewGeoJSONPoint (pt), jobProperties (j)))
+        }
+    }
+    return ret
+}
+
+// a point for each lead, leads without coordinates are left out
+func LeadsGeoJSON (leads []*Lead) *GeoJSONFeatureCollection {
+    ret := NewGeoJSONFeatureCollection()
+    for _, l := range leads {
+        if pt, ok := l.Location(); ok {
+            ret.Features = append (ret.Features, NewGeoJSONFeature (NewGeoJSONPoint (pt), leadProperties (l)))
+        }
+    }
+    return ret
+}
+
+// the stops as points, numbered in the order they're visited, and a line for each leg between them
+// including the depot and the trip back to it when the route has them
+func RouteGeoJSON (route *Route) *GeoJSONFeatureCollection {
+    ret := NewGeoJSONFeatureCollection()
+    if route.Depot.Valid() { ret.Features = append (ret.Features, geoJSONDepot (route.Depot)) }
+
+    prev, hasPrev := route.Depot, route.Depot.Valid()
+    for i, s := range route.Stops {
+        if hasPrev { ret.Features = append (ret.Features, geoJSONLeg (prev, s.Location, i + 1, s.Distance, s.Drive)) }
+
+        props := jobProperties (s.Job)
+        props["stop"] = i + 1
+        ret.Features = append (ret.Features, NewGeoJSONFeature (NewGeoJSONPoint (s.Location), props))
+
+        prev, hasPrev = s.Location, true
+    }
+
+    if route.ReturnDistance > 0 {
+        ret.Features = append (ret.Features, geoJSONLeg (prev, route.Depot, len(route.Stops) + 1, route.ReturnDistance, route.ReturnDrive))
+    }
+    return ret
+}
+
+// every tech's route from the plan, with the member on each feature so they can be colored by tech
+// the stops have their planned times, not the ones in workiz
+func DispatchGeoJSON (plan *DispatchPlan) *GeoJSONFeatureCollection {
+    ret := NewGeoJSONFeatureCollection()
+    for _, r := range plan.Routes {
+        if len(r.Stops) == 0 { continue }
+
+        tag := func (f *GeoJSONFeature) *GeoJSONFeature {
+            f.Properties["member_id"], f.Properties["member"] = r.Member.Id, r.Member.Name
+            return f
+        }
+
+        if r.Depot.Valid() { ret.Features = append (ret.Features, tag (geoJSONDepot (r.Depot))) }
+
+        prev, hasPrev := r.Depot, r.Depot.Valid()
+        for i, s := range r.Stops {
+            if s.Location.Valid() == false { continue } // booked work we don't have coordinates for
+
+            if hasPrev { ret.Features = append (ret.Features, tag (geoJSONLeg (prev, s.Location, i + 1, s.Distance, s.Drive))) }
+
+            var props map[string]interface{}
+            if s.Lead != nil {
+                props = leadProperties (s.Lead)
+            } else {
+                props = jobProperties (s.Job)
+            }
+            props["stop"], props["booked"] = i + 1, s.Booked
+            props["crew"] = []string{ r.Member.Name }
+            props["start"], props["end"], props["arrive"] = geoJSONTime (s.Start), geoJSONTime (s.End), geoJSONTime (s.Arrive)
+            ret.Features = append (ret.Features, tag (NewGeoJSONFeature (NewGeoJSONPoint (s.Location), props)))
+
+            prev, hasPrev = s.Location, true
+        }
+    }
+    return ret
+}
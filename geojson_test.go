@@ -0,0 +1,100 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestJobsGeoJSON (t *testing.T) {
+	jobs := []*Job {
+		testJob (t, "AAA111", "2023-02-28 09:00", 90, 44.47, -73.21, "1"),
+		testJob (t, "BBB222", "", 0, 44.48, -73.20),
+		testJob (t, "CCC333", "", 0, 0, 0), // left off the map
+	}
+
+	fc := JobsGeoJSON (jobs)
+	if assert.Equal (t, 2, len(fc.Features)) == false { return }
+
+	// round trip it so we're checking what a map would see
+	data, err := json.Marshal (fc)
+	if err != nil { t.Fatal (err) }
+
+	var out struct {
+		Type string
+		Features []struct {
+			Type string
+			Geometry struct {
+				Type string
+				Coordinates []float64
+			}
+			Properties map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal (data, &out); err != nil { t.Fatal (err) }
+
+	assert.Equal (t, "FeatureCollection", out.Type)
+	f := out.Features[0]
+	assert.Equal (t, "Feature", f.Type)
+	assert.Equal (t, "Point", f.Geometry.Type)
+	assert.Equal (t, []float64{ -73.21, 44.47 }, f.Geometry.Coordinates) // longitude first
+	assert.Equal (t, "job", f.Properties["kind"])
+	assert.Equal (t, "AAA111", f.Properties["id"])
+	assert.Equal (t, "Growler Fill", f.Properties["job_type"])
+	assert.Equal (t, []interface{}{ "tech 1" }, f.Properties["crew"])
+	assert.Equal (t, "2023-02-28T09:00:00", f.Properties["start"])
+	assert.Equal (t, "2023-02-28T10:30:00", f.Properties["end"])
+
+	// unscheduled
+	assert.Nil (t, out.Features[1].Properties["start"])
+	assert.Equal (t, []interface{}{}, out.Features[1].Properties["crew"])
+
+	// nothing is still a valid collection
+	data, _ = json.Marshal (JobsGeoJSON (nil))
+	assert.Equal (t, `{"type":"FeatureCollection","features":[]}`, string(data))
+}
+
+func TestLeadsGeoJSON (t *testing.T) {
+	lead := &Lead{}
+	err := json.Unmarshal ([]byte(`{"UUID":"LLL999","Latitude":"44.4669","Longitude":"-73.1709","LeadType":"Estimate","Team":[]}`), lead)
+	if err != nil { t.Fatal (err) }
+
+	fc := LeadsGeoJSON ([]*Lead{ lead, &Lead{} })
+	if assert.Equal (t, 1, len(fc.Features)) {
+		assert.Equal (t, "lead", fc.Features[0].Properties["kind"])
+		assert.Equal (t, "Estimate", fc.Features[0].Properties["job_type"])
+	}
+}
+
+func TestRouteGeoJSON (t *testing.T) {
+	depot := Point { Lat: 44.4, Lng: -73.20 }
+	jobs := []*Job {
+		testJob (t, "AAA111", "", 0, 44.4, -73.19, "1"),
+		testJob (t, "BBB222", "", 0, 44.4, -73.18, "1"),
+	}
+
+	// depot, 2 stops, 2 legs to them and 1 home
+	fc := RouteGeoJSON (PlanRoute (jobs, RouteOptions { Depot: depot, ReturnToDepot: true, AverageSpeed: 30 }))
+	kinds := map[string]int{}
+	for _, f := range fc.Features { kinds[f.Properties["kind"].(string)]++ }
+	assert.Equal (t, map[string]int{ "depot": 1, "job": 2, "leg": 3 }, kinds)
+
+	assert.Equal (t, 1, fc.Features[2].Properties["stop"])
+	assert.Equal (t, "LineString", fc.Features[1].Geometry.Type)
+	assert.Equal (t, `[[-73.2,44.4],[-73.19,44.4]]`, string(fc.Features[1].Geometry.Coordinates))
+
+	// no depot, just the leg between the stops
+	fc = RouteGeoJSON (PlanRoute (jobs, RouteOptions{}))
+	assert.Equal (t, 3, len(fc.Features))
+
+	// and the dispatch version tags everything with the tech
+	for _, j := range jobs { j.JobType = "" }
+	plan := Dispatch (jobs, testMembers()[:1], DispatchOptions { Day: time.Date (2023, 2, 28, 0, 0, 0, 0, time.UTC), Depot: depot, AverageSpeed: 30 })
+	fc = DispatchGeoJSON (plan)
+	if assert.Equal (t, 5, len(fc.Features)) {
+		for _, f := range fc.Features { assert.Equal (t, "1", f.Properties["member_id"]) }
+		assert.NotNil (t, fc.Features[2].Properties["arrive"])
+	}
+}
@@ -23,6 +23,9 @@ import (
 
 type JobStatus string 
 
+// when a job doesn't have an end time, this is how long we assume it takes
+const defaultJobDuration = time.Hour
+
 const (
 	JobStatus_submitted         = JobStatus("Submitted")
 )
@@ -31,52 +34,91 @@ const (
  //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
 //-----------------------------------------------------------------------------------------------------------------------//
 
-// Workiz has decided to return either an int or a string depending on what the user entered for the unit, so I need to try both
-type Unit struct {
-	Value string
-}
-
-// Implement UnmarshalJSON to handle both string and int
-func (this *Unit) UnmarshalJSON(data []byte) error {
-	// Attempt to unmarshal as a string
-	if err := json.Unmarshal(data, &this.Value); err == nil {
-		return nil
-	}
-
-	// If it's not a string, then try it as an int
-	var i int
-	if err := json.Unmarshal(data, &i); err == nil && i != 0 {
-		this.Value = fmt.Sprintf("%d", i)
-		return nil
-	}
-
-	return nil // i'm just going to ignore an error if this still doesn't work
-}
+// Workiz has decided to return either an int or a string depending on what the user entered for the unit
+// kept as its own name so existing code reading Unit.Value still works
+type Unit = FlexString
 
 type Job struct {
     UUID string
-    SerialId, ClientId int 
+    SerialId, ClientId FlexInt
     JobDateTime, JobEndDateTime, CreatedDate, PaymentDueDate, LastStatusUpdate workizTime
-    JobTotalPrice, JobAmountDue, SubTotal json.Number
+    JobTotalPrice, JobAmountDue, SubTotal Money
     SubStatus, JobType, ReferralCompany, Timezone, ServiceArea string 
     Phone, PhoneExt, SecondPhone, Email, FirstName, LastName, Company, JobNotes, JobSource, CreatedBy string 
     Address, City, State, PostalCode, Country string 
     Unit Unit
-    Latitude, Longitude float64 
-    ItemCost int `json:"item_cost"`
-    TechCost int `json:"tech_cost"`
+    Latitude, Longitude FlexFloat
+    ItemCost Money `json:"item_cost"`
+    TechCost Money `json:"tech_cost"`
     Status JobStatus
     Team []struct {
-        Id int `json:"id"`
+        Id FlexString `json:"id"`
         Name string `json:"name"`
     }
-    Comments workizComment
+    Comments Comments
+
+    // these only come back from job/get
+    LineItems []*LineItem `json:",omitempty"`
+    Payments []*Payment `json:",omitempty"`
+    Tax, Discount Money
+    Tags Tags `json:",omitempty"`
+    CustomFields CustomFields `json:",omitempty"`
+
+    Extra map[string]json.RawMessage `json:"-"` // anything workiz sent that we don't have a field for
+    drift []Drift
+}
+
+// unknown keys end up in Extra, and we remember the drift in case we're in strict mode
+func (this *Job) UnmarshalJSON (b []byte) error {
+    type alias Job // so we don't end up back in here
+    return decodeModel (b, (*alias)(this), "Job", &this.Extra, &this.drift)
+}
+
+// differences between the last response this was decoded from and our struct
+func (this *Job) SchemaDrift () []Drift {
+    return this.drift
+}
+
+// pulls together everything on the invoice
+// the line items and payments are added up ourselves, the rest is what workiz gave us
+func (this *Job) Totals () InvoiceTotals {
+    ret := InvoiceTotals {
+        SubTotal: this.SubTotal,
+        Tax: this.Tax,
+        Discount: this.Discount,
+        Total: this.JobTotalPrice,
+        AmountDue: this.JobAmountDue,
+    }
+
+    // it's all the same account, so the currencies can't actually clash here
+    for _, item := range this.LineItems {
+        ret.LineItems, _ = ret.LineItems.Add (item.Total())
+    }
+    for _, p := range this.Payments {
+        ret.Paid, _ = ret.Paid.Add (p.Amount)
+    }
+    return ret
+}
+
+// when the job starts and ends, with a default length if the end is missing or before the start
+func (this *Job) Window () (time.Time, time.Time) {
+    start, end := this.JobDateTime.Time, this.JobEndDateTime.Time
+    if end.After (start) == false { end = start.Add (defaultJobDuration) }
+    return start, end
+}
+
+// true if this member is on the job's crew
+func (this *Job) HasMember (id string) bool {
+    for _, t := range this.Team {
+        if t.Id.Value == id { return true }
+    }
+    return false
 }
 
 func (this *Job) toGeneric () (ret []*teamGeneric) {
     for _, t := range this.Team {
         ret = append(ret, &teamGeneric {
-            Id: fmt.Sprintf("%d", t.Id),
+            Id: t.Id.Value,
             Name: t.Name,
         })
     }
@@ -98,6 +140,23 @@ type CreateJob struct {
 type jobResponse struct {
     Flag, Has_more bool 
     Data []*Job
+    errs DecodeErrors // records we couldn't decode
+    count int // how many records were in the page, good or bad
+}
+
+// decodes the jobs one at a time so a single weird one doesn't fail the whole page
+func (this *jobResponse) UnmarshalJSON (b []byte) error {
+    var raw struct {
+        Flag, Has_more bool
+        Data []json.RawMessage
+    }
+    err := json.Unmarshal (b, &raw)
+    if err != nil { return err }
+
+    this.Flag, this.Has_more = raw.Flag, raw.Has_more
+    this.Data, this.errs = decodeRecords[Job] (raw.Data)
+    this.count = len(raw.Data)
+    return nil
 }
 
 // takes the jobs out of whatever this parent object is for
@@ -127,18 +186,41 @@ func (this *Workiz) GetJob (ctx context.Context, token, jobId string) (*Job, err
     if err != nil { return nil, err } // bail
     
     jobs := resp.toJobs(time.Time{}, time.Time{}) // pull out the jobs
-    if len(jobs) == 0 {
+    if len(jobs) == 0 && len(resp.errs) > 0 {
+        return nil, errors.Wrap (resp.errs, jobId) // it's there, we just can't read it
+    } else if len(jobs) == 0 {
         return nil, errors.Wrap (ErrNotFound, jobId)
     } else if len(jobs) > 1 {
         return nil, errors.Wrapf (ErrUnexpected, "More than 1 job found for id '%s'", jobId)
     }
 
+    reportDrift (this, jobs...)
+
     // we're here, we're good
     return jobs[0], nil
 }
 
 // returns all jobs that match our conditions
+// if any job can't be decoded the call fails with a DecodeErrors error, use ListJobsPartial to get the rest anyway
 func (this *Workiz) ListJobs (ctx context.Context, token string, start, end time.Time, status ...JobStatus) ([]*Job, error) {
+    ret, bad, err := this.ListJobsPartial (ctx, token, start, end, status...)
+    if err != nil { return nil, err }
+    if len(bad) > 0 { return nil, bad }
+    return ret, nil
+}
+
+// same as ListJobs, but jobs that can't be decoded are left out and come back on their own
+// so one weird job doesn't take out the whole day. the error is only for the call itself failing
+// with a Geocoder set, any jobs without coordinates get them filled in
+func (this *Workiz) ListJobsPartial (ctx context.Context, token string, start, end time.Time, status ...JobStatus) ([]*Job, DecodeErrors, error) {
+    ret, bad, err := this.listJobs (ctx, token, start, end, status...)
+    if this.Geocoder != nil { GeocodeJobs (ctx, this.Geocoder, ret) } // anything it can't place just stays without, like it came from workiz
+
+    return ret, bad, err
+}
+
+// pulls the pages for ListJobsPartial
+func (this *Workiz) listJobs (ctx context.Context, token string, start, end time.Time, status ...JobStatus) ([]*Job, DecodeErrors, error) {
     ret := make([]*Job, 0) // main list to return
     
     params := url.Values{}
@@ -159,24 +241,29 @@ func (this *Workiz) ListJobs (ctx context.Context, token string, start, end time
 
     // because unscheduled jobs come in with scheduled ones, we need to compare their ids to make sure we don't include unscheduled ones
     uMap := make(map[string]bool)
-    unscheduled, _ := this.ListUnscheduledJobs (ctx, token)
+    unscheduled, _, _ := this.ListUnscheduledJobsPartial (ctx, token)
     for _, u := range unscheduled {
         uMap[u.UUID] = true
     }
 
+    var bad DecodeErrors
+    seen := 0 // records so far, so the decode errors know where they were
     for i := 0; i < 10; i++ { // stay in a loop as long as we're pulling jobs
         params.Set("offset", fmt.Sprintf("%d", i)) // set our next page
         var resp jobResponse
         
         err := this.send (ctx, 0, http.MethodGet, token, fmt.Sprintf("job/all/?%s", params.Encode()), nil, &resp)
-        if err != nil { return nil, err } // bail
+        if err != nil { return nil, nil, err } // bail
         
         // we're here, we're good
         newJobs := resp.toJobs(start, end)
+        reportDrift (this, newJobs...)
+        bad = append (bad, resp.errs.offset (seen)...)
+        seen += resp.count
         
-        if len(newJobs) == 0 {
+        if len(newJobs) == 0 && len(resp.errs) == 0 {
             // means we didn't pull any more jobs from within our date range
-            return ret, nil 
+            return ret, bad, nil
         }
 
         for _, nj := range newJobs {
@@ -185,44 +272,76 @@ func (this *Workiz) ListJobs (ctx context.Context, token string, start, end time
             }
         }
 
-        if resp.Has_more == false { return ret, nil } // we're done
+        if resp.Has_more == false { return ret, bad, nil } // we're done
     }
-    return ret, errors.Wrapf (ErrTooManyRecords, "received over %d jobs in your history. %s - %s", len(ret), start, end)
+    return ret, bad, errors.Wrapf (ErrTooManyRecords, "received over %d jobs in your history. %s - %s", len(ret), start, end)
 }
 
 // lists the unscheduled jobs, which still have a job date and time... :shrug:
+// fails with a DecodeErrors error if any of them can't be decoded, same as ListJobs
 func (this *Workiz) ListUnscheduledJobs (ctx context.Context, token string) ([]*Job, error) {
+    ret, bad, err := this.ListUnscheduledJobsPartial (ctx, token)
+    if err != nil { return nil, err }
+    if len(bad) > 0 { return nil, bad }
+    return ret, nil
+}
+
+// ListUnscheduledJobs with the jobs we couldn't decode left out and returned on their own
+func (this *Workiz) ListUnscheduledJobsPartial (ctx context.Context, token string) ([]*Job, DecodeErrors, error) {
     ret := make([]*Job, 0) // main list to return
     
     params := url.Values{}
     params.Set("records", "100") // docs say 100 is the most you can request at a time
     params.Set("only_open", "true") // default
     
+    var bad DecodeErrors
+    seen := 0
     for i := 0; i < 10; i++ { // stay in a loop as long as we're pulling jobs
         params.Set("offset", fmt.Sprintf("%d", i)) // set our next page
         var resp jobResponse
         
         err := this.send (ctx, 0, http.MethodGet, token, fmt.Sprintf("job/all/?%s", params.Encode()), nil, &resp)
-        if err != nil { return nil, err } // bail
+        if err != nil { return nil, nil, err } // bail
         
         // we're here, we're good
         newJobs := resp.toJobs(time.Time{}, time.Time{})
+        reportDrift (this, newJobs...)
+        bad = append (bad, resp.errs.offset (seen)...)
+        seen += resp.count
         
-        if len(newJobs) == 0 {
+        if len(newJobs) == 0 && len(resp.errs) == 0 {
             // means we didn't pull any more jobs from within our date range
-            return ret, nil 
+            return ret, bad, nil
         }
 
         ret = append (ret, newJobs...)
 
-        if resp.Has_more == false { return ret, nil } // we're done
+        if resp.Has_more == false { return ret, bad, nil } // we're done
     }
-    return ret, errors.Wrapf (ErrTooManyRecords, "received over %d unscheduled jobs in your history.", len(ret))
+    return ret, bad, errors.Wrapf (ErrTooManyRecords, "received over %d unscheduled jobs in your history.", len(ret))
 }
 
 // updates the start/end time for a job
 // the time needs to be set to whatever timezone the user's account is in
 func (this *Workiz) UpdateJobSchedule (ctx context.Context, token, secret, jobId string, startTime time.Time, duration time.Duration) error {
+    if this.Preflight != nil {
+        // make sure the new time works for whoever is already on the job
+        existing, err := this.GetJob (ctx, token, jobId)
+        if err != nil { return err }
+
+        crew := Members{}
+        for _, t := range existing.toGeneric() { crew.Push (&Member { Id: t.Id, Name: t.Name }) }
+
+        loc, _ := existing.Location()
+        err = this.preflight (ctx, token, []*appointment{ preflightChange (jobId, startTime, startTime.Add (duration), crew, loc) }, crew)
+        if err != nil { return err }
+    }
+
+    return this.sendJobSchedule (ctx, token, secret, jobId, startTime, duration)
+}
+
+// the update itself, without the preflight
+func (this *Workiz) sendJobSchedule (ctx context.Context, token, secret, jobId string, startTime time.Time, duration time.Duration) error {
     var data struct {
         baseAuth
         UUID, Timezone string 
@@ -246,6 +365,17 @@ func (this *Workiz) UpdateJobCrew (ctx context.Context, token, secret, jobId str
     existing, err := this.GetJob (ctx, token, jobId)
     if err != nil { return err }
 
+    if this.Preflight != nil {
+        // check the crew we'll end up with, not the one the job has now
+        plan := planCrew (existing.toGeneric(), team, fullNames)
+        crew := append (append (append (Members{}, plan.Keeps...), plan.Adds...), plan.Stale...)
+
+        start, end := existing.Window()
+        loc, _ := existing.Location()
+        err = this.preflight (ctx, token, []*appointment{ preflightChange (jobId, start, end, crew, loc) }, crew)
+        if err != nil { return err }
+    }
+
     return this.handleCrew (ctx, existing.toGeneric(), token, secret, jobId, team, fullNames, this.AssignJobCrew, this.UnassignJobCrew)
 }
 
@@ -287,10 +417,13 @@ func (this *Workiz) UnassignJobCrew (ctx context.Context, token, secret, jobId s
 // jobs are created in the timezone of the account. so if we have the JobDateTime: "2022-12-18 15:00:00" it will create the job at 3pm est
 // so we need to convert this time from UTC to the local timezone for the account
 func (this *Workiz) CreateJob (ctx context.Context, token, secret string, job *CreateJob) (string, error) {
+    err := this.prepareCreate (ctx, Address { Address: job.Address, City: job.City, State: job.State, PostalCode: job.PostalCode }, &job.ServiceArea)
+    if err != nil { return "", err }
+
     job.AuthSecret = secret
     resp := &apiResp{}
     
-    err := this.send (ctx, 0, http.MethodPost, token, "job/create/", job, resp)
+    err = this.send (ctx, 0, http.MethodPost, token, "job/create/", job, resp)
     if err != nil { return "", err } // bail
     
     if resp.Flag == false || len(resp.Data) == 0 {
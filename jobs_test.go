@@ -3,10 +3,13 @@ package workiz
 
 import (
 	"github.com/stretchr/testify/assert"
+	"github.com/pkg/errors"
 
 	"testing"
 	"context"
 	"time"
+	"encoding/json"
+	stdErrors "errors"
 )
 
 func TestJobGet (t *testing.T) {
@@ -22,7 +25,7 @@ func TestJobGet (t *testing.T) {
 
 	assert.Equal (t, "OWX12J", job.UUID, "not filled in")
 	assert.Equal (t, 1, len(job.Comments))
-	assert.Equal (t, "this is a note, not a comment", job.Comments[0])
+	assert.Equal (t, "this is a note, not a comment", job.Comments[0].Text)
 	
 	/*
 	for _, j := range jobs {
@@ -44,7 +47,7 @@ func TestJobs (t *testing.T) {
 
 	assert.Equal (t, true, len(jobs) > 0, "expecting at least 1 job")
 	assert.NotEqual (t, "", jobs[0].UUID, "not filled in")
-	assert.NotEqual (t, "", jobs[0].ClientId, "not filled in")
+	assert.NotEqual (t, int64(0), jobs[0].ClientId.Value, "not filled in")
 	assert.NotEqual (t, "", jobs[0].Address, "not filled in")
 	
 	/*
@@ -68,7 +71,7 @@ func TestUnscheduledJobs (t *testing.T) {
 
 	assert.Equal (t, true, len(jobs) > 0, "expecting at least 1 job")
 	assert.NotEqual (t, "", jobs[0].UUID, "not filled in")
-	assert.NotEqual (t, "", jobs[0].ClientId, "not filled in")
+	assert.NotEqual (t, int64(0), jobs[0].ClientId.Value, "not filled in")
 	assert.NotEqual (t, "", jobs[0].Address, "not filled in")
 	
 	/*
@@ -78,3 +81,36 @@ func TestUnscheduledJobs (t *testing.T) {
 	*/
 }
 
+func TestJobResponsePartial (t *testing.T) {
+	resp := &jobResponse{}
+
+	// the middle job has an email that's an object, which can't go into our string
+	err := json.Unmarshal([]byte(`{"flag":true,"has_more":true,"data":[{"UUID":"AAA111","JobTotalPrice":"10.00"},{"UUID":"BBB222","Email":{"primary":"x"}},{"UUID":"CCC333"}]}`), resp)
+	if err != nil { t.Fatal(err) }
+
+	assert.Equal (t, true, resp.Has_more)
+	assert.Equal (t, 2, len(resp.Data))
+	assert.Equal (t, "AAA111", resp.Data[0].UUID)
+	assert.Equal (t, "CCC333", resp.Data[1].UUID)
+
+	assert.Equal (t, 1, len(resp.errs))
+	assert.Equal (t, 1, resp.errs[0].Index)
+	assert.Equal (t, "BBB222", resp.errs[0].UUID)
+
+	err = errors.Wrap (resp.errs.err(), "job/all")
+	assert.Equal (t, ErrPartialDecode, errors.Cause (err))
+
+	var bad DecodeErrors
+	assert.Equal (t, true, stdErrors.As (err, &bad))
+	assert.Equal (t, 1, len(bad))
+
+	assert.Nil (t, DecodeErrors(nil).err())
+
+	// on the next page the positions carry on from where this one left off
+	assert.Equal (t, 3, resp.count)
+	next := &jobResponse{}
+	err = json.Unmarshal([]byte(`{"flag":true,"data":[{"UUID":"DDD444","Email":[1]}]}`), next)
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, 3, next.errs.offset (resp.count)[0].Index)
+	assert.Equal (t, "DDD444", next.errs[0].UUID)
+}
@@ -14,6 +14,7 @@ import (
     "net/url"
     "context"
     "time"
+    "encoding/json"
 )
 
   //-----------------------------------------------------------------------------------------------------------------------//
@@ -25,26 +26,57 @@ import (
 //-----------------------------------------------------------------------------------------------------------------------//
 
 type Lead struct {
-    UUID, SerialId, ClientId string
+    UUID string
+    SerialId, ClientId FlexInt
     LeadDateTime, LeadEndDateTime, CreatedDate, PaymentDueDate, LastStatusUpdate workizTime
-    LeadTotalPrice, LeadAmountDue, SubTotal, SubStatus, LeadType, ReferralCompany, Timezone, ServiceArea string 
-    Phone, PhoneExt, SecondPhone, Email, Comments, FirstName, LastName, Company, LeadNotes, LeadSource, CreatedBy string 
+    LeadTotalPrice, LeadAmountDue, SubTotal Money
+    SubStatus, LeadType, JobType, ReferralCompany, Timezone, ServiceArea string 
+    Phone, PhoneExt, SecondPhone, Email, FirstName, LastName, Company, LeadNotes, LeadSource, CreatedBy string 
+    Comments Comments
     Address, City, State, PostalCode, Country string 
     Unit Unit
-    Latitude, Longitude string 
-    ItemCost string `json:"item_cost"`
-    TechCost string `json:"tech_cost"`
+    Latitude, Longitude FlexFloat
+    ItemCost Money `json:"item_cost"`
+    TechCost Money `json:"tech_cost"`
     Status JobStatus
     Team []struct {
-        Id string `json:"id"`
+        Id FlexString `json:"id"`
         Name string `json:"name"`
     }
+    Extra map[string]json.RawMessage `json:"-"` // anything workiz sent that we don't have a field for
+    drift []Drift
+}
+
+// same as jobs, extra keys are kept and the drift is tracked
+func (this *Lead) UnmarshalJSON (b []byte) error {
+    type alias Lead // so we don't end up back in here
+    return decodeModel (b, (*alias)(this), "Lead", &this.Extra, &this.drift)
+}
+
+// what didn't line up the last time this was decoded
+func (this *Lead) SchemaDrift () []Drift {
+    return this.drift
+}
+
+// same as a job's window, for a lead
+func (this *Lead) Window () (time.Time, time.Time) {
+    start, end := this.LeadDateTime.Time, this.LeadEndDateTime.Time
+    if end.After (start) == false { end = start.Add (defaultJobDuration) }
+    return start, end
+}
+
+// true if this member is on the lead's crew
+func (this *Lead) HasMember (id string) bool {
+    for _, t := range this.Team {
+        if t.Id.Value == id { return true }
+    }
+    return false
 }
 
 func (this *Lead) toGeneric () (ret []*teamGeneric) {
     for _, t := range this.Team {
         ret = append(ret, &teamGeneric {
-            Id: t.Id,
+            Id: t.Id.Value,
             Name: t.Name,
         })
     }
@@ -63,6 +95,23 @@ type leadResponse struct {
     Flag bool 
     Has_more bool 
     Data []*Lead
+    errs DecodeErrors
+    count int
+}
+
+// same as jobs, one bad lead shouldn't lose us the whole page
+func (this *leadResponse) UnmarshalJSON (b []byte) error {
+    var raw struct {
+        Flag, Has_more bool
+        Data []json.RawMessage
+    }
+    err := json.Unmarshal (b, &raw)
+    if err != nil { return err }
+
+    this.Flag, this.Has_more = raw.Flag, raw.Has_more
+    this.Data, this.errs = decodeRecords[Lead] (raw.Data)
+    this.count = len(raw.Data)
+    return nil
 }
 
 func (this leadResponse) toJobs (start, end time.Time) (ret []*Lead) {
@@ -90,18 +139,40 @@ func (this *Workiz) GetLead (ctx context.Context, token, leadId string) (*Lead,
     err := this.send (ctx, 0, http.MethodGet, token, fmt.Sprintf("lead/get/%s/", leadId), nil, resp)
     if err != nil { return nil, err } // bail
     
-    if len(resp.Data) == 0 {
+    if len(resp.Data) == 0 && len(resp.errs) > 0 {
+        return nil, errors.Wrap (resp.errs, leadId)
+    } else if len(resp.Data) == 0 {
         return nil, errors.Wrap (ErrNotFound, leadId)
     } else if len(resp.Data) > 1 {
         return nil, errors.Wrapf (ErrUnexpected, "More than 1 lead found for id '%s'", leadId)
     }
 
+    reportDrift (this, resp.Data...)
+
     // we're here, we're good
     return resp.Data[0], nil
 }
 
 // returns all leads that match our conditions
+// if any lead can't be decoded the call fails with a DecodeErrors error, use ListLeadsPartial to get the rest anyway
 func (this *Workiz) ListLeads (ctx context.Context, token string, start, end time.Time, status ...JobStatus) ([]*Lead, error) {
+    ret, bad, err := this.ListLeadsPartial (ctx, token, start, end, status...)
+    if err != nil { return nil, err }
+    if len(bad) > 0 { return nil, bad }
+    return ret, nil
+}
+
+// same as ListLeads, but leads that can't be decoded are left out and come back on their own
+// with a Geocoder set, any leads without coordinates get them filled in
+func (this *Workiz) ListLeadsPartial (ctx context.Context, token string, start, end time.Time, status ...JobStatus) ([]*Lead, DecodeErrors, error) {
+    ret, bad, err := this.listLeads (ctx, token, start, end, status...)
+    if this.Geocoder != nil { GeocodeLeads (ctx, this.Geocoder, ret) } // anything it can't place just stays without
+
+    return ret, bad, err
+}
+
+// pulls the pages for ListLeadsPartial
+func (this *Workiz) listLeads (ctx context.Context, token string, start, end time.Time, status ...JobStatus) ([]*Lead, DecodeErrors, error) {
     ret := make([]*Lead, 0) // main list to return
     
     params := url.Values{}
@@ -115,29 +186,34 @@ func (this *Workiz) ListLeads (ctx context.Context, token string, start, end tim
         params.Set("start_date", start.Format("2006-01-02"))
     }
 
+    var bad DecodeErrors
+    seen := 0
     for i := 0; i < 10; i++ { // stay in a loop as long as we're pulling leads
         params.Set("offset", fmt.Sprintf("%d", i)) // set our next page
         resp := &leadResponse{}
         
         err := this.send (ctx, 0, http.MethodGet, token, fmt.Sprintf("lead/all/?%s", params.Encode()), nil, resp)
-        if err != nil { return nil, err } // bail
+        if err != nil { return nil, nil, err } // bail
         
         // we're here, we're good
         leads := resp.toJobs (start, end) // use this to filter out leads outside of the date range
+        reportDrift (this, leads...)
+        bad = append (bad, resp.errs.offset (seen)...)
+        seen += resp.count
 
-        if len(leads) == 0 {
+        if len(leads) == 0 && len(resp.errs) == 0 {
             // we're done, all these are in the future
             // i do'nt know if this is correct, i'm just hoping they're ordered by target date
             // they're probably ordered by created, but this works close enough
-            return ret, nil 
+            return ret, bad, nil
         }
 
         // add them to our list
         ret = append (ret, leads...)
         
-        if resp.Has_more == false { return ret, nil } // we finished
+        if resp.Has_more == false { return ret, bad, nil } // we finished
     }
-    return ret, errors.Wrapf (ErrTooManyRecords, "received over %d leads in your history. %s - %s", len(ret), start, end)
+    return ret, bad, errors.Wrapf (ErrTooManyRecords, "received over %d leads in your history. %s - %s", len(ret), start, end)
 }
 
 // updates the start/end time for a lead at UTC
@@ -197,12 +273,15 @@ func (this *Workiz) UnassignLeadCrew (ctx context.Context, token, secret, leadId
 // creates a new lead in the system
 // returns the uuid of the newly created lead, so we can then assign crew members
 func (this *Workiz) CreateLead (ctx context.Context, token, secret string, lead *CreateLead) (string, error) {
+    err := this.prepareCreate (ctx, Address { Address: lead.Address, City: lead.City, State: lead.State, PostalCode: lead.PostalCode }, &lead.ServiceArea)
+    if err != nil { return "", err }
+
     lead.AuthSecret = secret
 
     // we need the id right away
     resp := &apiResp{}
     
-    err := this.send (ctx, 0, http.MethodPost, token, "lead/create/", lead, resp)
+    err = this.send (ctx, 0, http.MethodPost, token, "lead/create/", lead, resp)
     if err != nil { return "", err } // bail
     
     if resp.Flag == false || len(resp.Data) == 0 {
@@ -15,6 +15,11 @@ func TestLeadResponseStruct (t *testing.T) {
 
 	if err != nil { t.Fatal(err) }
 	assert.Equal (t, 4, len(resp.Data))
+	assert.Equal (t, int64(1002), resp.Data[0].ClientId.Value)
+	assert.Equal (t, 44.3998458, resp.Data[0].Latitude.Value)
+	assert.Equal (t, 0.0, resp.Data[3].Latitude.Value) // this one came in as an empty string
+	assert.Equal (t, "246389", resp.Data[1].Team[1].Id.Value)
+	assert.Equal (t, 0, len(DecodeWarnings (resp)))
 
 }
 
@@ -0,0 +1,248 @@
+/** ****************************************************************************************************************** **
+	Money handling
+	Workiz sends prices as strings, ints or floats depending on the field (and the mood)
+	so everything gets parsed into whole cents and we never touch a float
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "github.com/pkg/errors"
+
+    "fmt"
+    "math/big"
+    "regexp"
+    "strings"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// workiz doesn't tell us the currency, accounts are in dollars unless the caller says otherwise
+const DefaultCurrency = "USD"
+
+var (
+    ErrCurrencyMismatch = errors.New("Currencies don't match")
+    ErrInvalidMoney     = errors.New("Invalid money amount")
+)
+
+// big.Rat will happily take "1/3" or "1e2", but from workiz those mean the data's broken
+var plainDecimal = regexp.MustCompile (`^-?\d*(\.\d*)?$`)
+
+var currencySymbols = map[string]string {
+    "USD": "$",
+    "CAD": "$",
+    "AUD": "$",
+    "EUR": "€",
+    "GBP": "£",
+    "JPY": "¥",
+}
+
+// currencies that don't use decimals, we still keep hundredths but they get rounded off for showing
+var zeroDecimalCurrencies = map[string]bool {
+    "JPY": true,
+    "KRW": true,
+    "VND": true,
+    "CLP": true,
+    "ISK": true,
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// an amount of money stored as whole cents so adding up a report doesn't drift
+// an empty currency means "the account's currency" and will match anything
+type Money struct {
+    Cents int64
+    Currency string
+}
+
+// creates money from a whole number of cents
+func Cents (cents int64, currency string) Money {
+    return Money { Cents: cents, Currency: currency }
+}
+
+// parses a decimal string like "123.45", "-0.5" or "1,200" into money
+// anything past 2 decimal places is rounded half away from zero
+func ParseMoney (s, currency string) (Money, error) {
+    s = strings.TrimSpace (s)
+    s = strings.TrimLeft (s, "$€£")
+    s = strings.ReplaceAll (s, ",", "")
+    if len(s) == 0 { return Money { Currency: currency }, nil } // empty is just zero
+    if plainDecimal.MatchString (s) == false { return Money{}, errors.Wrap (ErrInvalidMoney, s) }
+
+    r, ok := new(big.Rat).SetString (s)
+    if ok == false { return Money{}, errors.Wrap (ErrInvalidMoney, s) }
+
+    r.Mul (r, big.NewRat (100, 1))
+    cents, err := roundRat (r)
+    if err != nil { return Money{}, errors.Wrap (err, s) }
+
+    return Money { Cents: cents, Currency: currency }, nil
+}
+
+// rounds a rational number to the nearest whole number, half away from zero
+func roundRat (r *big.Rat) (int64, error) {
+    num := new(big.Int).Set (r.Num())
+    den := r.Denom()
+
+    neg := num.Sign() < 0
+    num.Abs (num)
+
+    // (num * 2 + den) / (den * 2) rounds half up for positive numbers
+    num.Mul (num, big.NewInt(2))
+    num.Add (num, den)
+    num.Quo (num, new(big.Int).Mul (den, big.NewInt(2)))
+
+    if num.IsInt64() == false { return 0, ErrInvalidMoney } // way too much money
+
+    ret := num.Int64()
+    if neg { ret = -ret }
+    return ret, nil
+}
+
+// returns the currency, falling back to the default when it wasn't set
+func (this Money) CurrencyCode () string {
+    if len(this.Currency) == 0 { return DefaultCurrency }
+    return this.Currency
+}
+
+// figures out which currency the result of combining these 2 should be
+func (this Money) combine (other Money) (string, error) {
+    if len(this.Currency) == 0 { return other.Currency, nil }
+    if len(other.Currency) == 0 || strings.EqualFold (this.Currency, other.Currency) { return this.Currency, nil }
+
+    return "", errors.Wrapf (ErrCurrencyMismatch, "%s vs %s", this.Currency, other.Currency)
+}
+
+func (this Money) Add (other Money) (Money, error) {
+    cur, err := this.combine (other)
+    if err != nil { return Money{}, err }
+
+    return Money { Cents: this.Cents + other.Cents, Currency: cur }, nil
+}
+
+func (this Money) Sub (other Money) (Money, error) {
+    cur, err := this.combine (other)
+    if err != nil { return Money{}, err }
+
+    return Money { Cents: this.Cents - other.Cents, Currency: cur }, nil
+}
+
+// multiplies by a whole quantity, like the number of items on a line
+func (this Money) Mul (qty int64) Money {
+    return Money { Cents: this.Cents * qty, Currency: this.Currency }
+}
+
+// multiplies by a decimal rate, like "0.15" for a 15% commission
+// rounds half away from zero to the nearest cent
+func (this Money) MulRate (rate string) (Money, error) {
+    rate = strings.TrimSpace (rate)
+    if plainDecimal.MatchString (rate) == false { return Money{}, errors.Wrapf (ErrInvalidMoney, "rate : %s", rate) }
+
+    r, ok := new(big.Rat).SetString (rate)
+    if ok == false { return Money{}, errors.Wrapf (ErrInvalidMoney, "rate : %s", rate) }
+
+    r.Mul (r, new(big.Rat).SetInt64 (this.Cents))
+    cents, err := roundRat (r)
+    if err != nil { return Money{}, err }
+
+    return Money { Cents: cents, Currency: this.Currency }, nil
+}
+
+func (this Money) Neg () Money {
+    return Money { Cents: -this.Cents, Currency: this.Currency }
+}
+
+func (this Money) IsZero () bool {
+    return this.Cents == 0
+}
+
+func (this Money) IsNegative () bool {
+    return this.Cents < 0
+}
+
+// compares the amounts, -1 if we're less, 0 if equal, 1 if we're more
+// different currencies can't be compared, same as they can't be added
+func (this Money) Cmp (other Money) (int, error) {
+    if _, err := this.combine (other); err != nil { return 0, err }
+
+    switch {
+    case this.Cents < other.Cents: return -1, nil
+    case this.Cents > other.Cents: return 1, nil
+    }
+    return 0, nil
+}
+
+// plain decimal string, "1234.50"
+func (this Money) String () string {
+    cents := this.Cents
+    sign := ""
+    if cents < 0 {
+        sign = "-"
+        cents = -cents
+    }
+    return fmt.Sprintf ("%s%d.%02d", sign, cents / 100, cents % 100)
+}
+
+// for showing to a person, "$1,234.50" or "-$12.00"
+// currencies without decimals are rounded to the whole amount, "¥1,235"
+func (this Money) Format () string {
+    code := strings.ToUpper (this.CurrencyCode())
+
+    cents := this.Cents
+    sign := ""
+    if cents < 0 {
+        sign = "-"
+        cents = -cents
+    }
+
+    whole, frac := fmt.Sprintf ("%d", cents / 100), fmt.Sprintf (".%02d", cents % 100)
+    if zeroDecimalCurrencies[code] {
+        whole, frac = fmt.Sprintf ("%d", (cents + 50) / 100), "" // half away from zero, the sign's already off
+    }
+
+    // add the thousands separators
+    var sb strings.Builder
+    for i, c := range whole {
+        if i > 0 && (len(whole) - i) % 3 == 0 { sb.WriteByte (',') }
+        sb.WriteRune (c)
+    }
+
+    if sb.String() == "0" && len(frac) == 0 { sign = "" } // -¥0.40 rounds to nothing, not "-¥0"
+
+    symbol, ok := currencySymbols[code]
+    if ok == false {
+        return fmt.Sprintf ("%s%s%s %s", sign, sb.String(), frac, code)
+    }
+    return fmt.Sprintf ("%s%s%s%s", sign, symbol, sb.String(), frac)
+}
+
+// workiz gives us "12.50", 12.5, 12, "" or null. all of them are fine
+func (this *Money) UnmarshalJSON (b []byte) error {
+    s := strings.Trim (string(b), "\"")
+    if s == "null" { s = "" }
+
+    m, err := ParseMoney (s, this.Currency)
+    if err != nil { return err }
+
+    *this = m
+    return nil
+}
+
+// goes back out as a plain json number
+func (this Money) MarshalJSON () ([]byte, error) {
+    return []byte(this.String()), nil
+}
+
+// adds up a list of amounts, they all need to be in the same currency
+func SumMoney (vals ...Money) (ret Money, err error) {
+    for _, v := range vals {
+        ret, err = ret.Add (v)
+        if err != nil { return Money{}, err }
+    }
+    return
+}
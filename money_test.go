@@ -0,0 +1,96 @@
+package workiz
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"testing"
+	"encoding/json"
+)
+
+func TestMoneyParse (t *testing.T) {
+	m, err := ParseMoney ("1,234.565", "")
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, int64(123457), m.Cents)
+
+	m, err = ParseMoney ("-0.005", "")
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, int64(-1), m.Cents)
+
+	m, err = ParseMoney ("", "")
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, true, m.IsZero())
+
+	_, err = ParseMoney ("twelve", "")
+	assert.Error (t, err)
+
+	// fractions and exponents parse as numbers, but they're not amounts
+	for _, bad := range []string { "1/3", "1e2", "1E-2", "0x10", "1.2.3", "--1" } {
+		_, err = ParseMoney (bad, "")
+		assert.Equal (t, ErrInvalidMoney, errors.Cause (err), bad)
+	}
+
+	m, err = ParseMoney ("$.5", "")
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, int64(50), m.Cents)
+}
+
+func TestMoneyMath (t *testing.T) {
+	// the classic float problem, 0.1 + 0.2
+	a, _ := ParseMoney ("0.10", "")
+	b, _ := ParseMoney ("0.20", "USD")
+
+	sum, err := a.Add (b)
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, "0.30", sum.String())
+	assert.Equal (t, "USD", sum.Currency)
+
+	_, err = sum.Add (Cents (100, "EUR"))
+	assert.Error (t, err)
+
+	cmp, err := sum.Cmp (Cents (25, ""))
+	assert.NoError (t, err)
+	assert.Equal (t, 1, cmp)
+
+	_, err = sum.Cmp (Cents (25, "EUR"))
+	assert.Equal (t, ErrCurrencyMismatch, errors.Cause (err))
+
+	_, err = Cents (100, "").MulRate ("1/3")
+	assert.Equal (t, ErrInvalidMoney, errors.Cause (err))
+
+	commission, err := Cents (19999, "").MulRate ("0.15")
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, int64(3000), commission.Cents) // 29.9985 rounds up
+
+	total, err := SumMoney (Cents (100, ""), Cents (250, ""), Cents (-50, ""))
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, int64(300), total.Cents)
+}
+
+func TestMoneyFormat (t *testing.T) {
+	assert.Equal (t, "$1,234,567.08", Cents (123456708, "").Format())
+	assert.Equal (t, "-$12.00", Cents (-1200, "USD").Format())
+	assert.Equal (t, "12.00 NZD", Cents (1200, "NZD").Format())
+
+	// no decimals, so it rounds to the whole amount
+	assert.Equal (t, "¥5", Cents (500, "JPY").Format())
+	assert.Equal (t, "-¥1,235", Cents (-123450, "JPY").Format())
+	assert.Equal (t, "¥0", Cents (-40, "JPY").Format())
+	assert.Equal (t, "12 ISK", Cents (1200, "isk").Format())
+}
+
+func TestMoneyJSON (t *testing.T) {
+	var job Job
+	err := json.Unmarshal ([]byte(`{"JobTotalPrice":"120.50","JobAmountDue":12.5,"SubTotal":null,"item_cost":7,"tech_cost":""}`), &job)
+	if err != nil { t.Fatal(err) }
+
+	assert.Equal (t, int64(12050), job.JobTotalPrice.Cents)
+	assert.Equal (t, int64(1250), job.JobAmountDue.Cents)
+	assert.Equal (t, true, job.SubTotal.IsZero())
+	assert.Equal (t, int64(700), job.ItemCost.Cents)
+	assert.Equal (t, true, job.TechCost.IsZero())
+
+	b, err := json.Marshal (job.JobTotalPrice)
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, "120.50", string(b))
+}
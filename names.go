@@ -0,0 +1,293 @@
+/** ****************************************************************************************************************** **
+	Matching team member names
+	What people type for a name rarely matches what's in workiz exactly
+	extra spaces, accents, nicknames, just a last name... this tries each of those in order
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "strings"
+    "unicode"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// how sure we are about a name match, higher is better
+type MatchConfidence int
+
+const (
+    MatchConfidence_none        MatchConfidence = iota
+    MatchConfidence_fuzzy       // off by a typo
+    MatchConfidence_partial     // just the first or last name, or an initial and the last name
+    MatchConfidence_nickname    // "Bob Smith" for "Robert Smith"
+    MatchConfidence_normalized  // same once spaces, case, punctuation and accents are ignored
+    MatchConfidence_exact       // same name, ignoring case
+)
+
+func (this MatchConfidence) String () string {
+    switch this {
+    case MatchConfidence_fuzzy:         return "fuzzy"
+    case MatchConfidence_partial:       return "partial"
+    case MatchConfidence_nickname:      return "nickname"
+    case MatchConfidence_normalized:    return "normalized"
+    case MatchConfidence_exact:         return "exact"
+    }
+    return "none"
+}
+
+// accented letters that don't come apart into a letter and a mark
+var nameFold = map[rune]string {
+    'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ą': "a",
+    'ç': "c", 'ć': "c", 'č': "c",
+    'ď': "d", 'đ': "d",
+    'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ę': "e", 'ě': "e",
+    'ğ': "g",
+    'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ı': "i",
+    'ł': "l",
+    'ñ': "n", 'ń': "n", 'ň': "n",
+    'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ő': "o",
+    'ř': "r",
+    'ś': "s", 'š': "s", 'ş': "s",
+    'ť': "t",
+    'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ů': "u", 'ű': "u",
+    'ý': "y", 'ÿ': "y",
+    'ź': "z", 'ż': "z", 'ž': "z",
+    'ß': "ss", 'æ': "ae", 'œ': "oe",
+}
+
+// common nicknames, each group is treated as the same first name
+var nicknameGroups = [][]string {
+    { "robert", "rob", "robbie", "bob", "bobby" },
+    { "william", "will", "bill", "billy", "liam" },
+    { "james", "jim", "jimmy", "jamie" },
+    { "john", "jack", "johnny", "jon" },
+    { "jonathan", "jon", "jonny" },
+    { "michael", "mike", "mikey", "mick" },
+    { "richard", "rick", "ricky", "rich", "dick" },
+    { "thomas", "tom", "tommy" },
+    { "joseph", "joe", "joey" },
+    { "jose", "pepe" },
+    { "christopher", "chris" },
+    { "christine", "chris", "christina", "tina" },
+    { "nicholas", "nick", "nicky" },
+    { "anthony", "tony" },
+    { "daniel", "dan", "danny" },
+    { "matthew", "matt" },
+    { "andrew", "andy", "drew" },
+    { "edward", "ed", "eddie", "ted" },
+    { "elizabeth", "liz", "lizzie", "beth", "betsy", "eliza" },
+    { "katherine", "catherine", "kate", "katie", "kathy", "cathy" },
+    { "jennifer", "jen", "jenny" },
+    { "margaret", "maggie", "meg", "peggy" },
+    { "patricia", "pat", "patty", "trish" },
+    { "patrick", "pat" },
+    { "alexander", "alex" },
+    { "alexandra", "alex", "sandra" },
+    { "samuel", "sam", "sammy" },
+    { "samantha", "sam" },
+    { "benjamin", "ben", "benny" },
+    { "nathan", "nathaniel", "nate" },
+    { "steven", "stephen", "steve" },
+    { "david", "dave" },
+    { "charles", "charlie", "chuck" },
+    { "timothy", "tim" },
+    { "gregory", "greg" },
+    { "jeffrey", "jeff" },
+    { "kenneth", "ken", "kenny" },
+    { "ronald", "ron" },
+    { "donald", "don" },
+    { "susan", "sue", "suzy" },
+    { "rebecca", "becky", "becca" },
+    { "deborah", "debbie", "deb" },
+}
+
+// name -> the groups it's in
+var nicknames = func () map[string][]int {
+    ret := make(map[string][]int)
+    for i, group := range nicknameGroups {
+        for _, n := range group { ret[n] = append (ret[n], i) }
+    }
+    return ret
+}()
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// the result of looking up a name
+// if more than one member matched equally well, Ambiguous is set, Member is nil and Candidates has all of them
+type NameMatch struct {
+    Member *Member
+    Confidence MatchConfidence
+    Ambiguous bool
+    Candidates Members
+}
+
+// true if we found exactly one member and we're at least this sure about it
+func (this *NameMatch) Found (min MatchConfidence) bool {
+    return this.Member != nil && this.Ambiguous == false && this.Confidence >= min
+}
+
+type indexedName struct {
+    member *Member
+    normal string // the whole name, normalized
+    parts []string // the normalized name split on spaces
+}
+
+// lets us look up members by name a few different ways without redoing the normalizing each time
+type NameIndex struct {
+    names []*indexedName
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// lower case, no accents, no punctuation, single spaces
+// "  José  O'Brien-Smith " -> "jose obrien smith"
+func normalizeName (name string) string {
+    var sb strings.Builder
+    for _, r := range strings.ToLower (name) {
+        if unicode.Is (unicode.Mn, r) { continue } // combining accent from a decomposed letter
+
+        if folded, ok := nameFold[r]; ok {
+            sb.WriteString (folded)
+            continue
+        }
+
+        switch {
+        case r == '\'' || r == '’' || r == '.':
+            // O'Brien and J.R. stay together
+        case unicode.IsLetter (r) || unicode.IsDigit (r):
+            sb.WriteRune (r)
+        default:
+            sb.WriteRune (' ') // spaces, hyphens, commas all split words
+        }
+    }
+    return strings.Join (strings.Fields (sb.String()), " ")
+}
+
+// true if these first names are the same person, either the same or in a nickname group together
+func sameFirstName (a, b string) bool {
+    if a == b { return true }
+    for _, ga := range nicknames[a] {
+        for _, gb := range nicknames[b] {
+            if ga == gb { return true }
+        }
+    }
+    return false
+}
+
+// number of single character edits to get from a to b
+func editDistance (a, b string) int {
+    ra, rb := []rune(a), []rune(b)
+    prev := make([]int, len(rb) + 1)
+    curr := make([]int, len(rb) + 1)
+    for j := range prev { prev[j] = j }
+
+    for i := 1; i <= len(ra); i++ {
+        curr[0] = i
+        for j := 1; j <= len(rb); j++ {
+            cost := 1
+            if ra[i-1] == rb[j-1] { cost = 0 }
+            curr[j] = minInt (minInt (prev[j] + 1, curr[j-1] + 1), prev[j-1] + cost)
+        }
+        prev, curr = curr, prev
+    }
+    return prev[len(rb)]
+}
+
+func minInt (a, b int) int {
+    if a < b { return a }
+    return b
+}
+
+// picks the result for a level, ambiguous if there's more than one distinct member
+func nameResult (found Members, confidence MatchConfidence) *NameMatch {
+    uniq := Members{}
+    for _, m := range found {
+        if uniq.ById (m.Id) == nil { uniq.Push (m) }
+    }
+
+    if len(uniq) == 1 {
+        return &NameMatch { Member: uniq[0], Confidence: confidence, Candidates: uniq }
+    }
+    return &NameMatch { Confidence: confidence, Ambiguous: true, Candidates: uniq }
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// builds an index for looking members up by name
+func (this Members) Index () *NameIndex {
+    ret := &NameIndex{}
+    for _, m := range this {
+        normal := normalizeName (m.Name)
+        ret.names = append (ret.names, &indexedName { member: m, normal: normal, parts: strings.Fields (normal) })
+    }
+    return ret
+}
+
+// shortcut for a single lookup, build an Index if you're doing a bunch
+func (this Members) Lookup (name string) *NameMatch {
+    return this.Index().Lookup (name)
+}
+
+// finds the member for a name, trying the most certain kind of match first
+// stops at the first level that finds anyone, so an exact match always beats a nickname
+func (this *NameIndex) Lookup (name string) *NameMatch {
+    // exact
+    var found Members
+    for _, n := range this.names {
+        if strings.EqualFold (strings.TrimSpace (n.member.Name), strings.TrimSpace (name)) { found.Push (n.member) }
+    }
+    if len(found) > 0 { return nameResult (found, MatchConfidence_exact) }
+
+    normal := normalizeName (name)
+    parts := strings.Fields (normal)
+    if len(parts) == 0 { return &NameMatch{} } // nothing to go on
+
+    // normalized
+    for _, n := range this.names {
+        if n.normal == normal { found.Push (n.member) }
+    }
+    if len(found) > 0 { return nameResult (found, MatchConfidence_normalized) }
+
+    // nickname, first name is in the same group and the rest of the name matches
+    if len(parts) > 1 {
+        for _, n := range this.names {
+            if len(n.parts) != len(parts) { continue }
+            if strings.Join (n.parts[1:], " ") != strings.Join (parts[1:], " ") { continue }
+            if sameFirstName (n.parts[0], parts[0]) { found.Push (n.member) }
+        }
+        if len(found) > 0 { return nameResult (found, MatchConfidence_nickname) }
+    }
+
+    // partial, just a first or last name, or "j thomas"
+    for _, n := range this.names {
+        if len(n.parts) == 0 { continue }
+        first, last := n.parts[0], n.parts[len(n.parts)-1]
+
+        if len(parts) == 1 {
+            if parts[0] == first || parts[0] == last { found.Push (n.member) }
+        } else if len(parts) == 2 && len(parts[0]) == 1 {
+            if strings.HasPrefix (first, parts[0]) && parts[1] == last { found.Push (n.member) }
+        }
+    }
+    if len(found) > 0 { return nameResult (found, MatchConfidence_partial) }
+
+    // fuzzy, allow a typo or two depending on how long the name is
+    allowed := 1
+    if len(normal) > 10 { allowed = 2 }
+    for _, n := range this.names {
+        if editDistance (n.normal, normal) <= allowed { found.Push (n.member) }
+    }
+    if len(found) > 0 { return nameResult (found, MatchConfidence_fuzzy) }
+
+    return &NameMatch{}
+}
@@ -0,0 +1,56 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"testing"
+)
+
+func TestNormalizeName (t *testing.T) {
+	assert.Equal (t, "jose obrien smith", normalizeName ("  José  O'Brien-Smith "))
+	assert.Equal (t, "jose", normalizeName ("José")) // decomposed accent
+	assert.Equal (t, "lukasz", normalizeName ("Łukasz"))
+}
+
+func TestNameLookup (t *testing.T) {
+	team := Members {
+		&Member { Id: "1", Name: "Jon Smith" },
+		&Member { Id: "2", Name: "José García" },
+		&Member { Id: "3", Name: "Robert Jones" },
+		&Member { Id: "4", Name: "Alissa Thomas" },
+		&Member { Id: "5", Name: "Nathan Thomas" },
+	}
+	index := team.Index()
+
+	tests := []struct {
+		name, id string
+		confidence MatchConfidence
+	}{
+		{ "jon smith", "1", MatchConfidence_exact },
+		{ "Jon Smith ", "1", MatchConfidence_exact },
+		{ "Jose Garcia", "2", MatchConfidence_normalized },
+		{ "Bob Jones", "3", MatchConfidence_nickname },
+		{ "Nate Thomas", "5", MatchConfidence_nickname },
+		{ "Alissa", "4", MatchConfidence_partial },
+		{ "R Jones", "3", MatchConfidence_partial },
+		{ "Alisa Thomas", "4", MatchConfidence_fuzzy },
+	}
+
+	for _, test := range tests {
+		match := index.Lookup (test.name)
+		if assert.Equal (t, true, match.Found (MatchConfidence_fuzzy), test.name) {
+			assert.Equal (t, test.id, match.Member.Id, test.name)
+			assert.Equal (t, test.confidence, match.Confidence, test.name)
+		}
+	}
+
+	// two people with the same last name
+	match := index.Lookup ("Thomas")
+	assert.Equal (t, true, match.Ambiguous)
+	assert.Nil (t, match.Member)
+	assert.Equal (t, 2, len(match.Candidates))
+	assert.Equal (t, false, match.Found (MatchConfidence_none))
+
+	assert.Equal (t, MatchConfidence_none, index.Lookup ("Someone Else").Confidence)
+	assert.Equal (t, false, team.Lookup ("Alissa").Found (MatchConfidence_nickname))
+}
@@ -80,6 +80,32 @@ func (this *Workiz) finish (req *http.Request, out interface{}) error {
 	return err // we're good
 }
 
+// spaces our requests out so we stay under RateLimit, shared across everything using this object
+// returns early if the context finishes while we're waiting
+func (this *Workiz) throttle (ctx context.Context) error {
+	if this.RateLimit <= 0 { return nil } // no limit
+
+	this.lock.Lock()
+	now := time.Now()
+	if this.nextSend.Before (now) { this.nextSend = now }
+
+	wait := this.nextSend.Sub (now)
+	this.nextSend = this.nextSend.Add (time.Second / time.Duration(this.RateLimit)) // reserve our spot
+	this.lock.Unlock()
+
+	if wait == 0 { return nil }
+
+	timer := time.NewTimer (wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
   //-----------------------------------------------------------------------------------------------------------------------//
  //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
 //-----------------------------------------------------------------------------------------------------------------------//
@@ -88,6 +114,7 @@ func (this *Workiz) finish (req *http.Request, out interface{}) error {
 // retries itself on a 429 - ErrQuota
 func (this *Workiz) send (ctx context.Context, retries int, requestType, token, link string, in, out interface{}) error {
 	if ctx.Err() != nil { return ctx.Err() } // bail on a context timeout
+	if err := this.throttle (ctx); err != nil { return err }
 
 	var jstr []byte 
 	var err error 
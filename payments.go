@@ -0,0 +1,114 @@
+/** ****************************************************************************************************************** **
+	Calls related to payments on jobs
+
+    
+** ****************************************************************************************************************** **/
+
+package workiz 
+
+import (
+    "github.com/pkg/errors"
+    
+    "fmt"
+    "net/http"
+    "context"
+    "time"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// the payment types workiz knows about, for Payment.Method
+const (
+    PaymentMethod_cash          = "cash"
+    PaymentMethod_check         = "check"
+    PaymentMethod_credit        = "credit"
+    PaymentMethod_other         = "other"
+)
+
+var (
+    ErrInvalidPayment   = errors.New("Invalid payment")
+    ErrOverpayment      = errors.New("Payment is more than the amount due")
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// makes sure the payment makes sense on its own, and against what's still owed on the job
+// job/get doesn't always send the amount due, so when it's zero we can't tell and don't hold the payment to it
+func validatePayment (payment *Payment, due Money) error {
+    if payment == nil { return errors.Wrap (ErrInvalidPayment, "missing payment") }
+    if payment.Amount.Cents <= 0 {
+        return errors.Wrapf (ErrInvalidPayment, "amount needs to be more than zero : %s", payment.Amount)
+    }
+    if len(payment.Method) == 0 {
+        return errors.Wrap (ErrInvalidPayment, "missing method")
+    }
+
+    if due.IsZero() { return nil }
+
+    cmp, err := payment.Amount.Cmp (due)
+    if err != nil { return err } // different currencies
+
+    if cmp > 0 {
+        return errors.Wrapf (ErrOverpayment, "%s paid, %s due", payment.Amount.Format(), due.Format())
+    }
+    return nil
+}
+
+// the wall clock time in the account's timezone, which is how workiz reads any time we send without one
+// when the job doesn't have a timezone we know, utc is as good a guess as any
+func accountNow (timezone string, now time.Time) time.Time {
+    loc, err := time.LoadLocation (timezone)
+    if err != nil || len(timezone) == 0 { loc = time.UTC }
+
+    return now.In (loc)
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// records a payment against a job
+// the job is pulled first so we don't record more than what's still due
+// if the date is empty it's set to now in the job's timezone, since workiz reads the date as the account's local time
+// if the job doesn't have a timezone it's now in utc, set the date yourself if that matters
+func (this *Workiz) AddJobPayment (ctx context.Context, token, secret, jobId string, payment *Payment) error {
+    job, err := this.GetJob (ctx, token, jobId)
+    if err != nil { return err }
+
+    err = validatePayment (payment, job.JobAmountDue)
+    if err != nil { return errors.Wrap (err, jobId) }
+
+    if payment.Date.IsZero() { payment.Date.Time = accountNow (job.Timezone, time.Now()) }
+
+    var data struct {
+        baseAuth
+        UUID, Reference string
+        Amount Money
+        Type string
+        Date workizTime
+    }
+    data.AuthSecret = secret
+    data.UUID = jobId
+    data.Amount = payment.Amount
+    data.Type = payment.Method
+    data.Date = payment.Date
+    data.Reference = payment.Reference
+
+    err = this.send (ctx, 0, http.MethodPost, token, fmt.Sprintf ("job/addPayment/%s/", jobId), data, nil)
+    if err != nil { return err } // bail
+
+    // we're here, we're good
+    return nil
+}
+
+// returns the payments that have been made on a job
+func (this *Workiz) ListJobPayments (ctx context.Context, token, jobId string) ([]*Payment, error) {
+    job, err := this.GetJob (ctx, token, jobId)
+    if err != nil { return nil, err }
+
+    return job.Payments, nil
+}
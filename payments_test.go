@@ -0,0 +1,46 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/pkg/errors"
+
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestValidatePayment (t *testing.T) {
+	due := Cents (5000, "")
+
+	assert.NoError (t, validatePayment (&Payment { Amount: Cents (5000, ""), Method: PaymentMethod_credit }, due))
+	assert.NoError (t, validatePayment (&Payment { Amount: Cents (1, "USD"), Method: PaymentMethod_cash }, due))
+
+	err := validatePayment (&Payment { Amount: Cents (5001, ""), Method: PaymentMethod_credit }, due)
+	assert.Equal (t, ErrOverpayment, errors.Cause (err))
+
+	err = validatePayment (&Payment { Amount: Cents (0, ""), Method: PaymentMethod_credit }, due)
+	assert.Equal (t, ErrInvalidPayment, errors.Cause (err))
+
+	err = validatePayment (&Payment { Amount: Cents (100, "") }, due)
+	assert.Equal (t, ErrInvalidPayment, errors.Cause (err))
+
+	err = validatePayment (&Payment { Amount: Cents (100, "EUR"), Method: PaymentMethod_cash }, Cents (5000, "USD"))
+	assert.Equal (t, ErrCurrencyMismatch, errors.Cause (err))
+
+	// nothing due usually means job/get left it out, so there's nothing to hold it to
+	assert.NoError (t, validatePayment (&Payment { Amount: Cents (5001, ""), Method: PaymentMethod_credit }, Money{}))
+}
+
+func TestPaymentDefaultDate (t *testing.T) {
+	// late evening in new york is already tomorrow in utc
+	now := time.Date (2023, 3, 1, 2, 30, 0, 0, time.UTC)
+
+	b, err := json.Marshal (workizTime { Time: accountNow ("America/New_York", now) })
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, `"2023-02-28 21:30:00"`, string(b))
+
+	// without a timezone we can use, it's utc
+	assert.Equal (t, now, accountNow ("", now))
+	assert.Equal (t, now, accountNow ("Not/AZone", now))
+	assert.Equal (t, time.UTC, accountNow ("", now.In (time.Local)).Location())
+}
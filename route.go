@@ -0,0 +1,206 @@
+/** ****************************************************************************************************************** **
+	Route ordering
+	Puts a tech's jobs for the day into a sensible driving order, nearest neighbour to get started
+	and then 2-opt to untangle any crossed paths
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "time"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// 2-opt keeps going while it finds improvements, this keeps a bad input from running forever
+const routeMaxPasses = 100
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type RouteOptions struct {
+    Depot Point // where the tech starts the day, when it's not valid the route starts from whichever job gives the shortest drive
+    ReturnToDepot bool // include the drive back to the depot at the end of the day
+    AverageSpeed float64 // mph for the drive time estimates, 0 leaves them out
+}
+
+type RouteStop struct {
+    Job *Job
+    Location Point
+    Distance float64 // miles from the previous stop, or the depot
+    Drive time.Duration // estimated time to get here from the previous stop
+}
+
+type Route struct {
+    Depot Point // where the route starts, not valid when it starts at the first stop
+    Stops []*RouteStop // in the order to visit them
+    Skipped []*Job // jobs without coordinates, we can't place them
+    Distance float64 // total miles, including the drive back when ReturnToDepot is set
+    Drive time.Duration // total estimated drive time
+    ReturnDistance float64 // miles from the last stop back to the depot
+    ReturnDrive time.Duration
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// distance between every pair of points
+func distanceMatrix (pts []Point) [][]float64 {
+    ret := make([][]float64, len(pts))
+    for i := range pts {
+        ret[i] = make([]float64, len(pts))
+        for j := range pts {
+            if i != j { ret[i][j] = DistanceMiles (pts[i], pts[j]) }
+        }
+    }
+    return ret
+}
+
+// visit order for the points, starting with index 0 when fixed is set
+// otherwise every point gets a turn at being first and we keep the shortest
+// when closed is true the path goes back to index 0 at the end, which 2-opt needs to account for
+func orderPoints (pts []Point, closed, fixed bool) []int {
+    if len(pts) == 0 { return nil }
+
+    dist := distanceMatrix (pts)
+    if fixed { return orderFrom (dist, 0, closed) }
+
+    var best []int
+    bestLen := 0.0
+    for first := range pts {
+        path := orderFrom (dist, first, false)
+        if l := pathLength (dist, path); best == nil || l < bestLen - 1e-9 {
+            best, bestLen = path, l
+        }
+    }
+    return best
+}
+
+// total distance along the path, not counting any drive back
+func pathLength (dist [][]float64, path []int) (ret float64) {
+    for k := 1; k < len(path); k++ { ret += dist[path[k-1]][path[k]] }
+    return
+}
+
+// visit order starting from first, which stays first
+func orderFrom (dist [][]float64, first int, closed bool) []int {
+    // nearest neighbour gets us a decent start
+    path := []int{ first }
+    used := make([]bool, len(dist))
+    used[first] = true
+
+    for len(path) < len(dist) {
+        last, next := path[len(path)-1], -1
+        for j := range dist {
+            if used[j] { continue }
+            if next < 0 || dist[last][j] < dist[last][next] { next = j }
+        }
+        used[next] = true
+        path = append (path, next)
+    }
+
+    // the distance after position k in the path, either to the next stop, back to the start, or nothing at all
+    after := func (k int) (int, bool) {
+        if k + 1 < len(path) { return path[k+1], true }
+        if closed { return path[0], true }
+        return 0, false
+    }
+
+    // 2-opt, flip any stretch of the path that makes it shorter
+    for pass := 0; pass < routeMaxPasses; pass++ {
+        improved := false
+
+        for i := 1; i < len(path) - 1; i++ {
+            for k := i + 1; k < len(path); k++ {
+                a, b, c := path[i-1], path[i], path[k]
+
+                before := dist[a][b]
+                flipped := dist[a][c]
+                if d, ok := after (k); ok {
+                    before += dist[c][d]
+                    flipped += dist[b][d]
+                }
+
+                if flipped < before - 1e-9 {
+                    for l, r := i, k; l < r; l, r = l + 1, r - 1 {
+                        path[l], path[r] = path[r], path[l]
+                    }
+                    improved = true
+                }
+            }
+        }
+
+        if improved == false { break }
+    }
+    return path
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// works out the order to visit the jobs in, using straight line distances
+// these should be a single tech's jobs for the day, jobs without coordinates end up in Skipped
+// without a depot it can start at any of the jobs, whichever makes for the shortest day
+func PlanRoute (jobs []*Job, opts RouteOptions) *Route {
+    ret := &Route{}
+
+    var located []*Job
+    var pts []Point
+
+    depot := opts.Depot.Valid()
+    if depot {
+        ret.Depot = opts.Depot
+        pts = append (pts, opts.Depot)
+    }
+
+    for _, j := range jobs {
+        pt, ok := j.Location()
+        if ok == false {
+            ret.Skipped = append (ret.Skipped, j)
+            continue
+        }
+        located = append (located, j)
+        pts = append (pts, pt)
+    }
+
+    order := orderPoints (pts, depot && opts.ReturnToDepot, depot)
+
+    var prev *Point
+    if depot {
+        prev = &opts.Depot
+        order = order[1:] // the depot isn't a stop
+    }
+
+    for _, idx := range order {
+        stop := &RouteStop { Location: pts[idx] }
+        if depot {
+            stop.Job = located[idx-1]
+        } else {
+            stop.Job = located[idx]
+        }
+
+        if prev != nil {
+            stop.Distance = DistanceMiles (*prev, stop.Location)
+            stop.Drive = DriveTime (stop.Distance, opts.AverageSpeed)
+        }
+
+        ret.Stops = append (ret.Stops, stop)
+        ret.Distance += stop.Distance
+        ret.Drive += stop.Drive
+        prev = &stop.Location
+    }
+
+    if depot && opts.ReturnToDepot && len(ret.Stops) > 0 {
+        ret.ReturnDistance = DistanceMiles (*prev, opts.Depot)
+        ret.ReturnDrive = DriveTime (ret.ReturnDistance, opts.AverageSpeed)
+        ret.Distance += ret.ReturnDistance
+        ret.Drive += ret.ReturnDrive
+    }
+    return ret
+}
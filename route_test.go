@@ -0,0 +1,61 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"testing"
+	"time"
+)
+
+func TestPlanRoute (t *testing.T) {
+	// everything along the same road, the depot in the middle
+	depot := Point { Lat: 44.4, Lng: -73.20 }
+	jobs := []*Job {
+		testJob (t, "CCC333", "", 60, 44.4, -73.16, "1"),
+		testJob (t, "AAA111", "", 60, 44.4, -73.19, "1"),
+		testJob (t, "BBB222", "", 60, 44.4, -73.215, "1"),
+		testJob (t, "DDD444", "", 60, 0, 0, "1"), // no coordinates
+	}
+
+	// nearest neighbour goes to A then B, then doubles back past the depot for C
+	// 2-opt should find going to B first is shorter
+	route := PlanRoute (jobs, RouteOptions { Depot: depot, AverageSpeed: 30 })
+	if assert.Equal (t, 3, len(route.Stops)) {
+		assert.Equal (t, "BBB222", route.Stops[0].Job.UUID)
+		assert.Equal (t, "AAA111", route.Stops[1].Job.UUID)
+		assert.Equal (t, "CCC333", route.Stops[2].Job.UUID)
+	}
+	if assert.Equal (t, 1, len(route.Skipped)) {
+		assert.Equal (t, "DDD444", route.Skipped[0].UUID)
+	}
+
+	total := 0.0
+	for _, s := range route.Stops { total += s.Distance }
+	assert.InDelta (t, total, route.Distance, 0.0001)
+	assert.InDelta (t, DistanceMiles (depot, Point { Lat: 44.4, Lng: -73.215 }) + DistanceMiles (Point { Lat: 44.4, Lng: -73.215 }, Point { Lat: 44.4, Lng: -73.16 }), route.Distance, 0.0001)
+	assert.Equal (t, DriveTime (route.Stops[0].Distance, 30), route.Stops[0].Drive)
+	assert.Equal (t, time.Duration(0), route.ReturnDrive)
+
+	// coming back to the depot it doesn't matter which way around we go, but it counts the trip home
+	route = PlanRoute (jobs, RouteOptions { Depot: depot, ReturnToDepot: true, AverageSpeed: 30 })
+	assert.Equal (t, 3, len(route.Stops))
+	assert.Equal (t, true, route.ReturnDistance > 0)
+	assert.InDelta (t, 2 * DistanceMiles (Point { Lat: 44.4, Lng: -73.215 }, Point { Lat: 44.4, Lng: -73.16 }), route.Distance, 0.0001)
+
+	// without a depot it starts at whichever end makes for the shortest day
+	route = PlanRoute (jobs, RouteOptions{})
+	if assert.Equal (t, 3, len(route.Stops)) {
+		assert.Equal (t, "CCC333", route.Stops[0].Job.UUID)
+		assert.Equal (t, 0.0, route.Stops[0].Distance)
+		assert.Equal (t, "BBB222", route.Stops[2].Job.UUID)
+	}
+
+	// even when the first job in the list is in the middle, which would mean doubling back
+	route = PlanRoute ([]*Job { jobs[1], jobs[0], jobs[2] }, RouteOptions{})
+	if assert.Equal (t, 3, len(route.Stops)) {
+		assert.Equal (t, "AAA111", route.Stops[1].Job.UUID)
+		assert.InDelta (t, DistanceMiles (Point { Lat: 44.4, Lng: -73.215 }, Point { Lat: 44.4, Lng: -73.16 }), route.Distance, 0.0001)
+	}
+
+	assert.Equal (t, 0, len(PlanRoute (nil, RouteOptions { Depot: depot }).Stops))
+}
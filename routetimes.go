@@ -0,0 +1,151 @@
+/** ****************************************************************************************************************** **
+	Route timing
+	Once a route's in order, each job's start follows from the drive and the job before it
+	this works those times out, compares them to what's in workiz now and sends any that moved
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "github.com/pkg/errors"
+
+    "context"
+    "fmt"
+    "time"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+var ErrInvalidRouteTiming = errors.New("Invalid route timing")
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type RouteTiming struct {
+    Start time.Time // when the tech leaves the depot, or gets to the first job when the route doesn't have one. wall clock, like the job times
+    Buffer time.Duration // added before each job for parking, setting up, and so on
+    Window time.Duration // how wide the arrival window is that we give the customer, 0 for just the start time
+    MinChange time.Duration // moves smaller than this aren't worth sending
+    Concurrency int // how many updates can be in flight when applying, defaults to 4
+}
+
+// where a job is now, and where it should be
+type ScheduleChange struct {
+    Job *Job
+    OldStart, OldEnd time.Time // zero if it wasn't scheduled
+    Start, End time.Time
+    WindowEnd time.Time // the customer should expect them between Start and this
+    Changed bool // needs to be sent, when it's false Start and End are left where they were
+    Err error // from sending it
+}
+
+// how far the job moved, 0 when it wasn't scheduled before
+func (this *ScheduleChange) Moved () time.Duration {
+    if this.OldStart.IsZero() { return 0 }
+    return this.Start.Sub (this.OldStart)
+}
+
+func (this *ScheduleChange) String () string {
+    switch {
+    case this.Changed == false:
+        return fmt.Sprintf ("%s stays at %s", this.Job.UUID, this.Start.Format (time.Kitchen))
+    case this.OldStart.IsZero():
+        return fmt.Sprintf ("%s scheduled at %s", this.Job.UUID, this.Start.Format (time.Kitchen))
+    }
+    return fmt.Sprintf ("%s moved %s from %s to %s", this.Job.UUID, this.Moved(), this.OldStart.Format (time.Kitchen), this.Start.Format (time.Kitchen))
+}
+
+type RescheduleSummary struct {
+    Changes []*ScheduleChange // every stop, in route order
+    Moved, Unchanged, Failed int
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// works out when each stop should start, keeping each job's current length
+// each one starts after the previous job ends plus the drive and the buffer
+// a job that would move less than MinChange stays put, and the next one works from where it really ends
+func RouteTimes (route *Route, opts RouteTiming) ([]*ScheduleChange, error) {
+    if opts.Start.IsZero() { return nil, errors.Wrap (ErrInvalidRouteTiming, "missing start") }
+
+    ret := make([]*ScheduleChange, 0, len(route.Stops))
+
+    at := opts.Start
+    for _, s := range route.Stops {
+        start, end := s.Job.Window()
+        c := &ScheduleChange { Job: s.Job }
+        if s.Job.JobDateTime.IsZero() == false { c.OldStart, c.OldEnd = start, end }
+
+        c.Start = at.Add (s.Drive + opts.Buffer)
+        c.End = c.Start.Add (end.Sub (start))
+        c.WindowEnd = c.Start.Add (opts.Window)
+
+        diff := c.Start.Sub (c.OldStart)
+        if diff < 0 { diff = -diff }
+        c.Changed = c.OldStart.IsZero() || (diff > 0 && diff >= opts.MinChange)
+
+        if c.Changed == false {
+            c.Start, c.End = c.OldStart, c.OldEnd
+            c.WindowEnd = c.Start.Add (opts.Window)
+        }
+
+        ret = append (ret, c)
+        at = c.End
+    }
+    return ret, nil
+}
+
+// works out the route's times and sends the ones that changed
+// everything is attempted, failures are on the changes and come back together as BatchErrors keyed by job id
+// with Preflight set, the whole route is checked at once before anything is sent, since checking each job
+// on its own would find it clashing with the next stop's old time. any conflicts come back as Conflicts and nothing is sent
+func (this *Workiz) ApplyRouteTimes (ctx context.Context, token, secret string, route *Route, opts RouteTiming) (*RescheduleSummary, error) {
+    changes, err := RouteTimes (route, opts)
+    if err != nil { return nil, err }
+
+    ret := &RescheduleSummary { Changes: changes }
+
+    var ids []string
+    var moving []*appointment
+    crew := Members{}
+    onCrew := make(map[string]bool)
+    byJob := make(map[string]*ScheduleChange)
+    for _, c := range ret.Changes {
+        if c.Changed == false {
+            ret.Unchanged++
+            continue
+        }
+        ids = append (ids, c.Job.UUID)
+        byJob[c.Job.UUID] = c
+
+        var jobCrew Members
+        for _, t := range c.Job.toGeneric() {
+            jobCrew.Push (&Member { Id: t.Id, Name: t.Name })
+            if onCrew[t.Id] == false { crew.Push (&Member { Id: t.Id, Name: t.Name }) }
+            onCrew[t.Id] = true
+        }
+
+        loc, _ := c.Job.Location()
+        moving = append (moving, preflightChange (c.Job.UUID, c.Start, c.End, jobCrew, loc))
+    }
+
+    err = this.preflight (ctx, token, moving, crew)
+    if err != nil { return ret, err }
+
+    _, bad := fanOut (ctx, ids, opts.Concurrency, func (ctx context.Context, id string) (bool, error) {
+        c := byJob[id]
+        return true, this.sendJobSchedule (ctx, token, secret, id, c.Start.UTC(), c.End.Sub (c.Start))
+    })
+
+    for _, b := range bad { byJob[b.Id].Err = b.Err }
+
+    ret.Failed = len(bad)
+    ret.Moved = len(ids) - ret.Failed
+    return ret, bad.err()
+}
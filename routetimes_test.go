@@ -0,0 +1,56 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/pkg/errors"
+
+	"testing"
+	"time"
+)
+
+func TestRouteTimes (t *testing.T) {
+	depot := Point { Lat: 44.4, Lng: -73.20 }
+	jobs := []*Job {
+		testJob (t, "AAA111", "2023-02-28 09:00", 60, 44.4, -73.19, "1"),
+		testJob (t, "BBB222", "2023-02-28 10:30", 90, 44.4, -73.18, "1"),
+		testJob (t, "CCC333", "", 0, 44.4, -73.17, "1"), // not scheduled yet
+	}
+
+	route := PlanRoute (jobs, RouteOptions { Depot: depot, AverageSpeed: 30 })
+	if assert.Equal (t, 3, len(route.Stops)) == false { return }
+
+	start := time.Date (2023, 2, 28, 8, 45, 0, 0, time.UTC)
+	opts := RouteTiming { Start: start, Buffer: 5 * time.Minute, Window: time.Hour, MinChange: 10 * time.Minute }
+
+	changes, err := RouteTimes (route, opts)
+	if err != nil { t.Fatal(err) }
+	if assert.Equal (t, 3, len(changes)) == false { return }
+
+	// about a 1 minute drive, plus the buffer, so it's close enough to 9 to leave alone
+	// and since it isn't moving, it keeps the times it has
+	a := changes[0]
+	assert.Equal (t, "AAA111", a.Job.UUID)
+	assert.Equal (t, true, start.Add (route.Stops[0].Drive + 5 * time.Minute).Before (a.OldStart))
+	assert.Equal (t, a.OldStart, a.Start)
+	assert.Equal (t, a.OldEnd, a.End)
+	assert.Equal (t, a.Start.Add (time.Hour), a.WindowEnd)
+	assert.Equal (t, false, a.Changed)
+
+	// follows right after where A really ends, so it moves up and keeps its 90 minutes
+	b := changes[1]
+	assert.Equal (t, a.OldEnd.Add (route.Stops[1].Drive + 5 * time.Minute), b.Start)
+	assert.Equal (t, 90 * time.Minute, b.End.Sub (b.Start))
+	assert.Equal (t, true, b.Changed)
+	assert.Equal (t, true, b.Moved() < 0)
+
+	// never scheduled, so it always gets sent, with the default length
+	c := changes[2]
+	assert.Equal (t, true, c.Changed)
+	assert.Equal (t, time.Duration(0), c.Moved())
+	assert.Equal (t, defaultJobDuration, c.End.Sub (c.Start))
+	assert.Equal (t, false, c.Start.Before (b.End))
+
+	// there's nothing to work from without a start
+	_, err = RouteTimes (route, RouteTiming{})
+	assert.Equal (t, ErrInvalidRouteTiming, errors.Cause (err))
+}
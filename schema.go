@@ -0,0 +1,193 @@
+/** ****************************************************************************************************************** **
+	Schema drift
+	Keeps any fields workiz sends that our structs don't know about
+	and, in strict mode, tells the caller when the shape of a response stops matching our models
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "encoding/json"
+    "reflect"
+    "sort"
+    "strings"
+    "sync"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type DriftKind string
+
+const (
+    DriftKind_new           = DriftKind("new")          // workiz sent a field we don't have
+    DriftKind_missing       = DriftKind("missing")      // we have a field workiz didn't send
+    DriftKind_type          = DriftKind("type changed") // the json type doesn't fit our field anymore
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// a single difference between what workiz sent and what our model expected
+type Drift struct {
+    Model, Field string
+    Kind DriftKind
+    Expected, Received string // json types, only set for DriftKind_type
+}
+
+// anything that tracks drift from when it was decoded
+type schemaModel interface {
+    SchemaDrift () []Drift
+}
+
+type schemaField struct {
+    name string // the name as it's declared in the struct
+    typ reflect.Type
+    optional bool // omitempty fields aren't reported as missing
+}
+
+// reflection isn't free, so we only figure out a struct's fields once
+var schemaCache sync.Map // reflect.Type -> map[string]schemaField
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// returns the json keys for a struct, lower cased since encoding/json matches them case insensitively
+func modelFields (typ reflect.Type) map[string]schemaField {
+    if cached, ok := schemaCache.Load (typ); ok { return cached.(map[string]schemaField) }
+
+    ret := make(map[string]schemaField)
+    addModelFields (typ, ret)
+
+    schemaCache.Store (typ, ret)
+    return ret
+}
+
+func addModelFields (typ reflect.Type, ret map[string]schemaField) {
+    for i := 0; i < typ.NumField(); i++ {
+        f := typ.Field(i)
+
+        tag := f.Tag.Get ("json")
+        if tag == "-" { continue }
+
+        name := strings.Split (tag, ",")[0]
+
+        if f.Anonymous && len(name) == 0 && f.Type.Kind() == reflect.Struct {
+            addModelFields (f.Type, ret) // embedded struct, the fields get promoted
+            continue
+        }
+        if f.PkgPath != "" { continue } // unexported
+
+        if len(name) == 0 { name = f.Name }
+        ret[strings.ToLower (name)] = schemaField { name: name, typ: f.Type, optional: strings.Contains (tag, ",omitempty") }
+    }
+}
+
+// what kind of json value this is
+func rawKind (raw json.RawMessage) string {
+    s := strings.TrimSpace (string(raw))
+    if len(s) == 0 { return "null" }
+
+    switch s[0] {
+    case '"': return "string"
+    case '{': return "object"
+    case '[': return "array"
+    case 't', 'f': return "bool"
+    case 'n': return "null"
+    }
+    return "number"
+}
+
+// what kind of json value we'd expect for this type
+// empty means anything goes, because the type decodes itself
+func expectedKind (typ reflect.Type) string {
+    unmarshaler := reflect.TypeOf ((*json.Unmarshaler)(nil)).Elem()
+    if typ.Implements (unmarshaler) || reflect.PtrTo (typ).Implements (unmarshaler) { return "" }
+
+    switch typ.Kind() {
+    case reflect.Ptr:
+        return expectedKind (typ.Elem())
+    case reflect.String:
+        return "string"
+    case reflect.Bool:
+        return "bool"
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+        reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+        reflect.Float32, reflect.Float64:
+        return "number"
+    case reflect.Slice, reflect.Array:
+        return "array"
+    case reflect.Struct, reflect.Map:
+        return "object"
+    }
+    return ""
+}
+
+// works out which keys won't land anywhere, then decodes the model like normal
+// the drift is worked out first so a type change is still recorded when it breaks the decode
+// v needs to be a pointer to an alias of the model so we don't recurse back into its UnmarshalJSON
+func decodeModel (b []byte, v interface{}, model string, extra *map[string]json.RawMessage, drift *[]Drift) error {
+    *extra = nil
+    *drift = nil
+
+    var raw map[string]json.RawMessage
+    if json.Unmarshal (b, &raw) == nil { // if it's not an object there's nothing to compare
+        *extra, *drift = compareModel (raw, modelFields (reflect.TypeOf (v).Elem()), model)
+    }
+
+    return json.Unmarshal (b, v)
+}
+
+func compareModel (raw map[string]json.RawMessage, fields map[string]schemaField, model string) (extra map[string]json.RawMessage, drift []Drift) {
+    seen := make(map[string]bool)
+
+    keys := make([]string, 0, len(raw))
+    for key := range raw { keys = append (keys, key) }
+    sort.Strings (keys) // so the drift comes out the same every time
+
+    for _, key := range keys {
+        f, ok := fields[strings.ToLower (key)]
+        if ok == false {
+            if extra == nil { extra = make(map[string]json.RawMessage) }
+            extra[key] = raw[key]
+            drift = append (drift, Drift { Model: model, Field: key, Kind: DriftKind_new, Received: rawKind (raw[key]) })
+            continue
+        }
+        seen[strings.ToLower (key)] = true
+
+        got, want := rawKind (raw[key]), expectedKind (f.typ)
+        if got != "null" && len(want) > 0 && got != want {
+            drift = append (drift, Drift { Model: model, Field: f.name, Kind: DriftKind_type, Expected: want, Received: got })
+        }
+    }
+
+    missing := make([]string, 0)
+    for key, f := range fields {
+        if seen[key] == false && f.optional == false { missing = append (missing, f.name) }
+    }
+    sort.Strings (missing)
+
+    for _, name := range missing {
+        drift = append (drift, Drift { Model: model, Field: name, Kind: DriftKind_missing })
+    }
+    return
+}
+
+// passes any drift from these models to the callback, once per field per call
+// missing fields only go out when ReportMissing is set, workiz skips so many that they'd drown out everything else
+func reportDrift[T schemaModel] (w *Workiz, models ...T) {
+    if w.Strict == false || w.OnDrift == nil { return }
+
+    sent := make(map[Drift]bool)
+    for _, m := range models {
+        for _, d := range m.SchemaDrift() {
+            if sent[d] || (d.Kind == DriftKind_missing && w.ReportMissing == false) { continue }
+            sent[d] = true
+            w.OnDrift (d)
+        }
+    }
+}
@@ -0,0 +1,85 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"testing"
+	"encoding/json"
+)
+
+func TestSchemaExtra (t *testing.T) {
+	lead := &Lead{}
+
+	err := json.Unmarshal ([]byte(`{"UUID":"SRUYUI","SerialId":"3","SecondPhoneExt":"","Truck":"Growler Van","Team":[]}`), lead)
+	if err != nil { t.Fatal(err) }
+
+	assert.Equal (t, "SRUYUI", lead.UUID)
+	assert.Equal (t, 2, len(lead.Extra))
+	assert.Equal (t, `"Growler Van"`, string(lead.Extra["Truck"]))
+
+	var added, missing int
+	for _, d := range lead.SchemaDrift() {
+		assert.Equal (t, "Lead", d.Model)
+		switch d.Kind {
+		case DriftKind_new: added++
+		case DriftKind_missing: missing++
+		}
+	}
+	assert.Equal (t, 2, added)
+	assert.Equal (t, true, missing > 0, "expecting the fields we didn't send to be missing")
+}
+
+func TestSchemaStrict (t *testing.T) {
+	var reported []Drift
+	w := &Workiz { Strict: true, OnDrift: func (d Drift) { reported = append (reported, d) } }
+
+	// email came back as a number, which breaks the decode, but we should still know why
+	one := &Member{}
+	err := json.Unmarshal ([]byte(`{"Id":"1","Name":"Nathan","Email":12,"Avatar":"a"}`), one)
+	assert.Error (t, err)
+
+	// both members have the same new field
+	two := &Member{}
+	err = json.Unmarshal ([]byte(`{"Id":"2","Name":"Brooklyn","Avatar":"b"}`), two)
+	if err != nil { t.Fatal(err) }
+
+	reportDrift (w, one, two)
+
+	var typeChanged, added int
+	for _, d := range reported {
+		switch d.Kind {
+		case DriftKind_type:
+			typeChanged++
+			assert.Equal (t, "Email", d.Field)
+			assert.Equal (t, "string", d.Expected)
+			assert.Equal (t, "number", d.Received)
+		case DriftKind_new:
+			added++
+			assert.Equal (t, "Avatar", d.Field)
+		}
+	}
+	assert.Equal (t, 1, typeChanged)
+	assert.Equal (t, 1, added, "should only be reported once per call")
+	assert.Equal (t, 2, len(reported), "missing fields need ReportMissing")
+
+	// and with it, everything we didn't get comes through too
+	reported = nil
+	w.ReportMissing = true
+	reportDrift (w, two)
+
+	var missing int
+	for _, d := range reported {
+		if d.Kind == DriftKind_missing {
+			missing++
+			assert.NotEqual (t, "Name", d.Field)
+		}
+	}
+	assert.Equal (t, true, missing > 0)
+	w.ReportMissing = false
+
+	// not strict, so nothing should be reported
+	reported = nil
+	w.Strict = false
+	reportDrift (w, one, two)
+	assert.Equal (t, 0, len(reported))
+}
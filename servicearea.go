@@ -0,0 +1,203 @@
+/** ****************************************************************************************************************** **
+	Service areas
+	Works out which service area an address is in, from polygons drawn around each area or a zip code table
+	so CreateJob and CreateLead don't depend on someone typing the area name right
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "github.com/pkg/errors"
+
+    "context"
+    "encoding/json"
+    "os"
+    "strings"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// the feature property we use for the area's name when one isn't given
+const defaultAreaProperty = "name"
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// picks the service area for an address, the point is invalid if we don't have coordinates for it
+// returns an empty string when it doesn't know
+type AreaResolver interface {
+    ResolveArea (addr Address, pt Point) string
+}
+
+// postal code to service area name
+// zip+4 codes fall back to their first 5 digits, and spaces don't matter so "K1A 0B1" and "K1A0B1" are the same
+type ZipAreas map[string]string
+
+// a service area drawn on a map
+type PolygonArea struct {
+    Name string
+    Polygons [][][]Point // each polygon is its outside ring, then any holes
+
+    min, max Point // the box around everything, so most points can be ruled out quickly
+}
+
+// checked in order, the first area containing the point wins
+// these need coordinates, so for CreateJob and CreateLead that means a Geocoder on the Workiz
+// without one they never match anything, put a ZipAreas after them in AreaResolvers to catch those
+type PolygonAreas []*PolygonArea
+
+// tries each resolver in order until one knows, like polygons first and then zips for anything without coordinates
+type AreaResolvers []AreaResolver
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+func normalizeZip (code string) string {
+    return strings.ToUpper (strings.ReplaceAll (strings.TrimSpace (code), " ", ""))
+}
+
+// ray casting, counts how many edges a line going east from the point crosses
+func inRing (pt Point, ring []Point) bool {
+    inside := false
+    for i, j := 0, len(ring) - 1; i < len(ring); j, i = i, i + 1 {
+        a, b := ring[i], ring[j]
+        if (a.Lat > pt.Lat) != (b.Lat > pt.Lat) &&
+            pt.Lng < (b.Lng - a.Lng) * (pt.Lat - a.Lat) / (b.Lat - a.Lat) + a.Lng {
+            inside = !inside
+        }
+    }
+    return inside
+}
+
+func (this *PolygonArea) bounds () {
+    first := true
+    for _, poly := range this.Polygons {
+        if len(poly) == 0 { continue }
+        for _, pt := range poly[0] { // holes are inside the outside ring anyway
+            if first {
+                this.min, this.max, first = pt, pt, false
+                continue
+            }
+            if pt.Lat < this.min.Lat { this.min.Lat = pt.Lat }
+            if pt.Lng < this.min.Lng { this.min.Lng = pt.Lng }
+            if pt.Lat > this.max.Lat { this.max.Lat = pt.Lat }
+            if pt.Lng > this.max.Lng { this.max.Lng = pt.Lng }
+        }
+    }
+}
+
+// checks the address and fills in the service area when the caller didn't pick one
+// the point only comes from the Geocoder, so without one the resolvers only get the address
+func (this *Workiz) prepareCreate (ctx context.Context, addr Address, serviceArea *string) error {
+    pt, err := this.locate (ctx, addr)
+    if err != nil { return err }
+
+    if this.ServiceAreas != nil && len(strings.TrimSpace (*serviceArea)) == 0 {
+        *serviceArea = this.ServiceAreas.ResolveArea (addr, pt)
+    }
+    return nil
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// reads a json object of postal codes to area names
+//  { "05401": "Burlington", "05602": "Montpelier" }
+func LoadZipAreas (path string) (ZipAreas, error) {
+    data, err := os.ReadFile (path)
+    if err != nil { return nil, errors.WithStack (err) }
+
+    var raw map[string]string
+    err = json.Unmarshal (data, &raw)
+    if err != nil { return nil, errors.Wrap (err, path) }
+
+    ret := make(ZipAreas, len(raw))
+    for code, area := range raw { ret[normalizeZip (code)] = area }
+    return ret, nil
+}
+
+func (this ZipAreas) ResolveArea (addr Address, pt Point) string {
+    code := normalizeZip (addr.PostalCode)
+    if len(code) == 0 { return "" }
+
+    if area, ok := this[code]; ok { return area }
+
+    if i := strings.IndexByte (code, '-'); i > 0 { code = code[:i] } // zip+4
+    if len(code) > 5 && len(strings.Trim (code, "0123456789")) == 0 { code = code[:5] } // zip+4 without the dash
+    return this[code]
+}
+
+// reads service areas from a GeoJSON feature collection of polygons and multipolygons
+// nameProperty is which property has the area's name, "name" when it's empty
+func ParsePolygonAreas (data []byte, nameProperty string) (PolygonAreas, error) {
+    if len(nameProperty) == 0 { nameProperty = defaultAreaProperty }
+
+    var fc GeoJSONFeatureCollection
+    err := json.Unmarshal (data, &fc)
+    if err != nil { return nil, errors.Wrap (ErrInvalidGeoJSON, err.Error()) }
+
+    ret := make(PolygonAreas, 0, len(fc.Features))
+    for i, f := range fc.Features {
+        name := f.StringProperty (nameProperty)
+        if len(name) == 0 { return nil, errors.Wrapf (ErrInvalidGeoJSON, "feature %d doesn't have a %s", i, nameProperty) }
+        if f.Geometry == nil { return nil, errors.Wrapf (ErrInvalidGeoJSON, "%s doesn't have a geometry", name) }
+
+        polys, err := f.Geometry.Polygons()
+        if err != nil { return nil, errors.Wrap (err, name) }
+
+        area := &PolygonArea { Name: name, Polygons: polys }
+        area.bounds()
+        ret = append (ret, area)
+    }
+    return ret, nil
+}
+
+// ParsePolygonAreas from a file
+func LoadPolygonAreas (path, nameProperty string) (PolygonAreas, error) {
+    data, err := os.ReadFile (path)
+    if err != nil { return nil, errors.WithStack (err) }
+
+    ret, err := ParsePolygonAreas (data, nameProperty)
+    return ret, errors.Wrap (err, path)
+}
+
+// true if the point is inside one of the area's polygons, and not in one of its holes
+func (this *PolygonArea) Contains (pt Point) bool {
+    if pt.Lat < this.min.Lat || pt.Lat > this.max.Lat || pt.Lng < this.min.Lng || pt.Lng > this.max.Lng { return false }
+
+    for _, poly := range this.Polygons {
+        if len(poly) == 0 || inRing (pt, poly[0]) == false { continue }
+
+        hole := false
+        for _, ring := range poly[1:] {
+            if inRing (pt, ring) {
+                hole = true
+                break
+            }
+        }
+        if hole == false { return true }
+    }
+    return false
+}
+
+func (this PolygonAreas) ResolveArea (addr Address, pt Point) string {
+    if pt.Valid() == false { return "" }
+
+    for _, area := range this {
+        if area.Contains (pt) { return area.Name }
+    }
+    return ""
+}
+
+func (this AreaResolvers) ResolveArea (addr Address, pt Point) string {
+    for _, r := range this {
+        if area := r.ResolveArea (addr, pt); len(area) > 0 { return area }
+    }
+    return ""
+}
@@ -0,0 +1,78 @@
+package workiz
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"context"
+	"testing"
+)
+
+// a square around burlington with a hole in the middle, and montpelier as a multipolygon
+const testAreas = `{"type":"FeatureCollection","features":[
+	{"type":"Feature","properties":{"name":"Burlington"},"geometry":{"type":"Polygon","coordinates":[
+		[[-73.30,44.40],[-73.10,44.40],[-73.10,44.55],[-73.30,44.55],[-73.30,44.40]],
+		[[-73.22,44.47],[-73.20,44.47],[-73.20,44.49],[-73.22,44.49],[-73.22,44.47]]
+	]}},
+	{"type":"Feature","properties":{"name":"Montpelier"},"geometry":{"type":"MultiPolygon","coordinates":[
+		[[[-72.65,44.20],[-72.50,44.20],[-72.50,44.32],[-72.65,44.32],[-72.65,44.20]]],
+		[[[-72.45,44.20],[-72.40,44.20],[-72.40,44.25],[-72.45,44.20]]]
+	]}}
+]}`
+
+func TestPolygonAreas (t *testing.T) {
+	areas, err := ParsePolygonAreas ([]byte(testAreas), "")
+	if err != nil { t.Fatal (err) }
+	assert.Equal (t, 2, len(areas))
+
+	assert.Equal (t, "Burlington", areas.ResolveArea (Address{}, Point { Lat: 44.45, Lng: -73.25 }))
+	assert.Equal (t, "", areas.ResolveArea (Address{}, Point { Lat: 44.48, Lng: -73.21 })) // in the hole
+	assert.Equal (t, "Montpelier", areas.ResolveArea (Address{}, Point { Lat: 44.26, Lng: -72.57 }))
+	assert.Equal (t, "Montpelier", areas.ResolveArea (Address{}, Point { Lat: 44.21, Lng: -72.42 })) // the second polygon
+	assert.Equal (t, "", areas.ResolveArea (Address{}, Point { Lat: 40.71, Lng: -74.00 }))
+	assert.Equal (t, "", areas.ResolveArea (Address{}, Point{}))
+
+	_, err = ParsePolygonAreas ([]byte(testAreas), "area")
+	assert.Equal (t, ErrInvalidGeoJSON, errors.Cause (err))
+
+	_, err = ParsePolygonAreas ([]byte(`{"type":"FeatureCollection","features":[{"type":"Feature","properties":{"name":"x"},"geometry":{"type":"Point","coordinates":[1,2]}}]}`), "")
+	assert.Equal (t, ErrInvalidGeoJSON, errors.Cause (err))
+}
+
+func TestZipAreas (t *testing.T) {
+	zips := ZipAreas { "05401": "Burlington", "K1A0B1": "Ottawa" }
+
+	assert.Equal (t, "Burlington", zips.ResolveArea (Address { PostalCode: "05401" }, Point{}))
+	assert.Equal (t, "Burlington", zips.ResolveArea (Address { PostalCode: "05401-1234" }, Point{}))
+	assert.Equal (t, "Burlington", zips.ResolveArea (Address { PostalCode: " 054011234" }, Point{}))
+	assert.Equal (t, "Ottawa", zips.ResolveArea (Address { PostalCode: "k1a 0b1" }, Point{}))
+	assert.Equal (t, "", zips.ResolveArea (Address { PostalCode: "K1A0B" }, Point{}))
+	assert.Equal (t, "", zips.ResolveArea (Address{}, Point{}))
+}
+
+func TestPrepareCreate (t *testing.T) {
+	areas, err := ParsePolygonAreas ([]byte(testAreas), "name")
+	if err != nil { t.Fatal (err) }
+
+	w := &Workiz {
+		Geocoder: &countingGeocoder { known: map[string]Point { "123 Main St": { Lat: 44.45, Lng: -73.25 } } },
+		ServiceAreas: AreaResolvers { areas, ZipAreas { "05602": "Montpelier" } },
+	}
+	ctx := context.Background()
+
+	// from the polygons
+	job := &CreateJob { Address: "123 Main St", PostalCode: "05401" }
+	assert.NoError (t, w.prepareCreate (ctx, Address { Address: job.Address, PostalCode: job.PostalCode }, &job.ServiceArea))
+	assert.Equal (t, "Burlington", job.ServiceArea)
+
+	// the caller's pick wins
+	job.ServiceArea = "Essex"
+	assert.NoError (t, w.prepareCreate (ctx, Address { Address: job.Address, PostalCode: job.PostalCode }, &job.ServiceArea))
+	assert.Equal (t, "Essex", job.ServiceArea)
+
+	// no geocoder, so it falls back to the zip
+	w.Geocoder = nil
+	lead := &CreateLead { Address: "5 State St", PostalCode: "05602" }
+	assert.NoError (t, w.prepareCreate (ctx, Address { Address: lead.Address, PostalCode: lead.PostalCode }, &lead.ServiceArea))
+	assert.Equal (t, "Montpelier", lead.ServiceArea)
+}
@@ -0,0 +1,212 @@
+/** ****************************************************************************************************************** **
+	Spatial index
+	Buckets jobs and leads into a grid so we can ask what's near somewhere without checking every record
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "math"
+    "sort"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+const (
+    defaultCellMiles    = 1.0
+    milesPerDegree      = earthRadiusMiles * math.Pi / 180 // north to south, east to west shrinks towards the poles
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// a job or a lead in the index, only one of them is set
+type Place struct {
+    Location Point
+    Job *Job
+    Lead *Lead
+}
+
+// the job or lead uuid
+func (this *Place) Id () string {
+    if this.Job != nil { return this.Job.UUID }
+    return this.Lead.UUID
+}
+
+// a place that matched a query, and how far it is
+type Nearby struct {
+    *Place
+    Distance float64 // miles
+}
+
+type gridCell struct {
+    x, y int
+}
+
+// jobs and leads bucketed by location
+// it isn't safe to add to while other goroutines are querying it
+type SpatialIndex struct {
+    Missing []*Place // added without coordinates, so they're not in any query
+
+    cellDeg float64
+    cells map[gridCell][]*Place
+    count int
+    min, max Point // bounds of everything in the index
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+func (this *SpatialIndex) cell (pt Point) gridCell {
+    return gridCell { x: int(math.Floor (pt.Lng / this.cellDeg)), y: int(math.Floor (pt.Lat / this.cellDeg)) }
+}
+
+func (this *SpatialIndex) add (p *Place) {
+    if p.Location.Valid() == false {
+        this.Missing = append (this.Missing, p)
+        return
+    }
+
+    if this.count == 0 {
+        this.min, this.max = p.Location, p.Location
+    } else {
+        this.min = Point { Lat: math.Min (this.min.Lat, p.Location.Lat), Lng: math.Min (this.min.Lng, p.Location.Lng) }
+        this.max = Point { Lat: math.Max (this.max.Lat, p.Location.Lat), Lng: math.Max (this.max.Lng, p.Location.Lng) }
+    }
+    this.count++
+
+    c := this.cell (p.Location)
+    this.cells[c] = append (this.cells[c], p)
+}
+
+// everything in the cells that overlap the box, it still needs to be checked against the box itself
+func (this *SpatialIndex) scan (sw, ne Point, fn func (*Place)) {
+    // a box bigger than what we have is just everything
+    if sw.Lat <= this.min.Lat && sw.Lng <= this.min.Lng && ne.Lat >= this.max.Lat && ne.Lng >= this.max.Lng {
+        for _, places := range this.cells {
+            for _, p := range places { fn (p) }
+        }
+        return
+    }
+
+    lo, hi := this.cell (sw), this.cell (ne)
+    if (hi.x - lo.x + 1) * (hi.y - lo.y + 1) > len(this.cells) {
+        // more cells in the box than we have filled, faster to just go through the ones we have
+        for c, places := range this.cells {
+            if c.x < lo.x || c.x > hi.x || c.y < lo.y || c.y > hi.y { continue }
+            for _, p := range places { fn (p) }
+        }
+        return
+    }
+
+    for x := lo.x; x <= hi.x; x++ {
+        for y := lo.y; y <= hi.y; y++ {
+            for _, p := range this.cells[gridCell { x: x, y: y }] { fn (p) }
+        }
+    }
+}
+
+func sortNearby (list []*Nearby) {
+    sort.SliceStable (list, func (i, j int) bool {
+        if list[i].Distance != list[j].Distance { return list[i].Distance < list[j].Distance }
+        return list[i].Id() < list[j].Id()
+    })
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// builds an index over the jobs and leads, either can be nil
+// cellMiles is the size of the grid squares, around the radius you usually search with works best. 0 uses 1 mile
+func NewSpatialIndex (jobs []*Job, leads []*Lead, cellMiles float64) *SpatialIndex {
+    if cellMiles <= 0 { cellMiles = defaultCellMiles }
+
+    ret := &SpatialIndex { cellDeg: cellMiles / milesPerDegree, cells: make(map[gridCell][]*Place) }
+    ret.AddJobs (jobs...)
+    ret.AddLeads (leads...)
+    return ret
+}
+
+func (this *SpatialIndex) AddJobs (jobs ...*Job) {
+    for _, j := range jobs {
+        pt, _ := j.Location()
+        this.add (&Place { Location: pt, Job: j })
+    }
+}
+
+func (this *SpatialIndex) AddLeads (leads ...*Lead) {
+    for _, l := range leads {
+        pt, _ := l.Location()
+        this.add (&Place { Location: pt, Lead: l })
+    }
+}
+
+// how many places can be found, not counting the ones without coordinates
+func (this *SpatialIndex) Len () int {
+    return this.count
+}
+
+// everything inside the box, sw is the bottom left corner and ne the top right
+// boxes that cross the date line aren't supported
+func (this *SpatialIndex) InBox (sw, ne Point) []*Place {
+    ret := make([]*Place, 0)
+    this.scan (sw, ne, func (p *Place) {
+        if p.Location.Lat >= sw.Lat && p.Location.Lat <= ne.Lat && p.Location.Lng >= sw.Lng && p.Location.Lng <= ne.Lng {
+            ret = append (ret, p)
+        }
+    })
+    sort.SliceStable (ret, func (i, j int) bool { return ret[i].Id() < ret[j].Id() })
+    return ret
+}
+
+// everything within this many miles, closest first
+func (this *SpatialIndex) Within (center Point, miles float64) []*Nearby {
+    ret := make([]*Nearby, 0)
+    if this.count == 0 || miles < 0 { return ret }
+
+    // the box around the circle, degrees of longitude get shorter the further you are from the equator
+    dLat := miles / milesPerDegree
+    dLng := 360.0
+    if cos := math.Cos (center.Lat * math.Pi / 180); cos > 0.01 { dLng = math.Min (360, dLat / cos) }
+
+    sw := Point { Lat: center.Lat - dLat, Lng: center.Lng - dLng }
+    ne := Point { Lat: center.Lat + dLat, Lng: center.Lng + dLng }
+
+    this.scan (sw, ne, func (p *Place) {
+        if d := DistanceMiles (center, p.Location); d <= miles {
+            ret = append (ret, &Nearby { Place: p, Distance: d })
+        }
+    })
+    sortNearby (ret)
+    return ret
+}
+
+// the n closest places, closest first
+func (this *SpatialIndex) Nearest (center Point, n int) []*Nearby {
+    if n <= 0 || this.count == 0 { return make([]*Nearby, 0) }
+
+    // once the circle is past the farthest corner of everything we have, we just take it all
+    farthest := 0.0
+    for _, corner := range []Point { this.min, this.max, { Lat: this.min.Lat, Lng: this.max.Lng }, { Lat: this.max.Lat, Lng: this.min.Lng } } {
+        farthest = math.Max (farthest, DistanceMiles (center, corner))
+    }
+
+    // keep widening the circle until we have enough, the grid keeps each try cheap
+    miles := this.cellDeg * milesPerDegree
+    for {
+        ret := this.Within (center, miles)
+        if len(ret) >= n || math.IsInf (miles, 1) {
+            if len(ret) > n { ret = ret[:n] }
+            return ret
+        }
+
+        miles *= 2
+        if miles > farthest { miles = math.Inf (1) } // last try, everything
+    }
+}
@@ -0,0 +1,61 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"encoding/json"
+	"testing"
+)
+
+func TestSpatialIndex (t *testing.T) {
+	jobs := []*Job {
+		testJob (t, "AAA111", "", 0, 44.4759, -73.2121), // burlington
+		testJob (t, "BBB222", "", 0, 44.4906, -73.1860), // winooski, about 1.6 miles
+		testJob (t, "CCC333", "", 0, 44.2601, -72.5754), // montpelier, about 35 miles
+		testJob (t, "DDD444", "", 0, 0, 0), // no idea
+	}
+
+	// leads send their coordinates as strings
+	lead := &Lead{}
+	err := json.Unmarshal ([]byte(`{"UUID":"LLL999","Latitude":"44.4669","Longitude":"-73.1709","Team":[]}`), lead) // south burlington
+	if err != nil { t.Fatal (err) }
+
+	idx := NewSpatialIndex (jobs, []*Lead{ lead }, 0)
+	assert.Equal (t, 4, idx.Len())
+	if assert.Equal (t, 1, len(idx.Missing)) {
+		assert.Equal (t, "DDD444", idx.Missing[0].Id())
+	}
+
+	center := Point { Lat: 44.4759, Lng: -73.2121 }
+
+	near := idx.Within (center, 5)
+	if assert.Equal (t, 3, len(near)) {
+		assert.Equal (t, "AAA111", near[0].Id())
+		assert.Equal (t, 0.0, near[0].Distance)
+		assert.Equal (t, "BBB222", near[1].Id())
+		assert.Equal (t, "LLL999", near[2].Id())
+		assert.NotNil (t, near[2].Lead)
+		assert.Nil (t, near[2].Job)
+	}
+
+	assert.Equal (t, 4, len(idx.Within (center, 50)))
+	assert.Equal (t, 1, len(idx.Within (center, 0.1)))
+
+	// just montpelier
+	box := idx.InBox (Point { Lat: 44.2, Lng: -72.6 }, Point { Lat: 44.3, Lng: -72.5 })
+	if assert.Equal (t, 1, len(box)) {
+		assert.Equal (t, "CCC333", box[0].Id())
+	}
+	assert.Equal (t, 4, len(idx.InBox (Point { Lat: 40, Lng: -80 }, Point { Lat: 50, Lng: -70 })))
+
+	// the closest 2 to montpelier, has to search well past the first cell
+	closest := idx.Nearest (Point { Lat: 44.2601, Lng: -72.5754 }, 2)
+	if assert.Equal (t, 2, len(closest)) {
+		assert.Equal (t, "CCC333", closest[0].Id())
+		assert.Equal (t, "LLL999", closest[1].Id())
+	}
+
+	// asking for more than there are gives everything
+	assert.Equal (t, 4, len(idx.Nearest (center, 10)))
+	assert.Equal (t, 0, len(NewSpatialIndex (nil, nil, 2).Nearest (center, 3)))
+}
@@ -0,0 +1,155 @@
+/** ****************************************************************************************************************** **
+	Technician suggestions
+	Ranks the team for a job based on skills, service areas, how busy they already are that day
+	and how far they'd have to drive from their last job
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "fmt"
+    "sort"
+    "time"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// how much each thing counts towards a suggestion's score, out of 100
+const (
+    suggestSkillWeight      = 40.0
+    suggestAreaWeight       = 30.0
+    suggestWorkloadWeight   = 20.0
+    suggestDistanceWeight   = 10.0
+
+    suggestJobPenalty       = 5.0   // each job they already have that day takes this off the workload score
+    suggestMaxMiles         = 30.0  // past this the distance score is 0
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type Suggestion struct {
+    Member *Member
+    Score float64 // 0 to 100, higher is better
+    SkillMatch, AreaMatch bool
+    JobsThatDay int
+    Distance float64 // miles from their previous job that day, -1 when we can't tell
+    Reasons []string // why they scored the way they did, for showing a dispatcher
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+func sameDay (a, b time.Time) bool {
+    ay, am, ad := a.Date()
+    by, bm, bd := b.In (a.Location()).Date()
+    return ay == by && am == bm && ad == bd
+}
+
+// how the member lines up against the job, nil if they can't take it at all
+// listing skills without the one the job needs counts as can't, we only guess when they haven't listed any
+func suggestMember (job *Job, m *Member, schedule []*Job) *Suggestion {
+    if m.Active == false || m.FieldTech == false { return nil }
+
+    start, end := job.Window()
+    ret := &Suggestion { Member: m, Distance: -1 }
+
+    // skills, members without any listed get half credit since we don't know
+    // a job without a type doesn't need anything in particular, so everyone gets full credit
+    switch {
+    case len(job.JobType) == 0:
+        ret.Score += suggestSkillWeight
+        ret.Reasons = append (ret.Reasons, "no job type to match")
+    case len(m.Skills) == 0:
+        ret.Score += suggestSkillWeight / 2
+        ret.Reasons = append (ret.Reasons, "no skills listed")
+    case m.HasSkill (job.JobType):
+        ret.SkillMatch = true
+        ret.Score += suggestSkillWeight
+        ret.Reasons = append (ret.Reasons, "has skill " + job.JobType)
+    default:
+        return nil // they can't do this kind of job
+    }
+
+    // service areas, same idea, but being outside their area is only a lower score
+    switch {
+    case len(job.ServiceArea) == 0:
+        ret.Score += suggestAreaWeight / 2
+        ret.Reasons = append (ret.Reasons, "no service area on the job")
+    case len(m.ServiceAreas) == 0:
+        ret.Score += suggestAreaWeight / 2
+        ret.Reasons = append (ret.Reasons, "no service areas listed")
+    case m.Covers (job.ServiceArea):
+        ret.AreaMatch = true
+        ret.Score += suggestAreaWeight
+        ret.Reasons = append (ret.Reasons, "covers " + job.ServiceArea)
+    default:
+        ret.Reasons = append (ret.Reasons, "doesn't cover " + job.ServiceArea)
+    }
+
+    // what else they have that day, and where they'll be coming from
+    var previous *Job
+    for _, other := range schedule {
+        if other.UUID == job.UUID || other.HasMember (m.Id) == false { continue }
+
+        oStart, oEnd := other.Window()
+        if sameDay (start, oStart) == false { continue }
+
+        if oStart.Before (end) && start.Before (oEnd) { return nil } // already busy then
+
+        ret.JobsThatDay++
+        if oEnd.After (start) == false && (previous == nil || oStart.After (previous.JobDateTime.Time)) {
+            previous = other
+        }
+    }
+
+    workload := suggestWorkloadWeight - suggestJobPenalty * float64(ret.JobsThatDay)
+    if workload < 0 { workload = 0 }
+    ret.Score += workload
+    ret.Reasons = append (ret.Reasons, fmt.Sprintf ("%d other jobs that day", ret.JobsThatDay))
+
+    here, ok := job.Location()
+    if previous != nil && ok {
+        if there, ok := previous.Location(); ok {
+            ret.Distance = DistanceMiles (there, here)
+        }
+    }
+
+    if ret.Distance < 0 {
+        ret.Score += suggestDistanceWeight / 2 // unknown, so middle of the road
+    } else {
+        if ret.Distance < suggestMaxMiles {
+            ret.Score += suggestDistanceWeight * (1 - ret.Distance / suggestMaxMiles)
+        }
+        ret.Reasons = append (ret.Reasons, fmt.Sprintf ("%.1f miles from their previous job", ret.Distance))
+    }
+    return ret
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// ranks the members who could take this job, best first
+// schedule should be the jobs already booked around the job's date, with their crews
+// inactive members, office staff, anyone missing the job's skill and anyone already busy during the job are left out
+func SuggestCrew (job *Job, members Members, schedule []*Job) []*Suggestion {
+    ret := make([]*Suggestion, 0, len(members))
+    for _, m := range members {
+        if s := suggestMember (job, m, schedule); s != nil {
+            ret = append (ret, s)
+        }
+    }
+
+    sort.SliceStable (ret, func (i, j int) bool {
+        if ret[i].Score != ret[j].Score { return ret[i].Score > ret[j].Score }
+        if ret[i].JobsThatDay != ret[j].JobsThatDay { return ret[i].JobsThatDay < ret[j].JobsThatDay }
+        return ret[i].Member.Name < ret[j].Member.Name
+    })
+    return ret
+}
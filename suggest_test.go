@@ -0,0 +1,84 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"testing"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// builds a job through json, the same way workiz would send it
+func testJob (t *testing.T, uuid, start string, minutes int, lat, lng float64, crew ...string) *Job {
+	team := make([]string, 0, len(crew))
+	for _, c := range crew { team = append (team, fmt.Sprintf (`{"id":%s,"name":"tech %s"}`, c, c)) }
+
+	end := "null"
+	if len(start) > 0 {
+		st, err := time.Parse ("2006-01-02 15:04", start)
+		if err != nil { t.Fatal(err) }
+		start = `"` + st.Format ("2006-01-02 15:04:05") + `"`
+		end = `"` + st.Add (time.Duration(minutes) * time.Minute).Format ("2006-01-02 15:04:05") + `"`
+	} else {
+		start = "null" // unscheduled
+	}
+
+	job := &Job{}
+	err := json.Unmarshal ([]byte(fmt.Sprintf (`{"UUID":"%s","JobDateTime":%s,"JobEndDateTime":%s,"Latitude":"%f","Longitude":"%f","JobType":"Growler Fill","ServiceArea":"Burlington","Team":[%s]}`,
+		uuid, start, end, lat, lng, strings.Join (team, ","))), job)
+	if err != nil { t.Fatal(err) }
+	return job
+}
+
+func testMembers () Members {
+	yes := true
+	return Members {
+		&Member { Id: "1", Name: "Nathan Thomas", Active: yes, FieldTech: yes, Skills: []string{"Growler Fill"}, ServiceAreas: []string{"Burlington"} },
+		&Member { Id: "2", Name: "Brooklyn Thomas", Active: yes, FieldTech: yes, Skills: []string{"Full Case"}, ServiceAreas: []string{"Burlington"} },
+		&Member { Id: "3", Name: "Alissa Thomas", Active: yes, FieldTech: yes },
+		&Member { Id: "4", Name: "Office Person", Active: yes },
+	}
+}
+
+func TestSuggestCrew (t *testing.T) {
+	job := testJob (t, "NEW111", "2023-02-28 13:00", 60, 44.4759, -73.2121) // burlington
+
+	schedule := []*Job {
+		testJob (t, "AAA111", "2023-02-28 09:00", 60, 44.3998, -73.2037, "1"), // shelburne, earlier that day
+		testJob (t, "BBB222", "2023-02-28 13:30", 60, 44.4759, -73.2121, "3"), // overlaps, so 3 is out
+		testJob (t, "CCC333", "2023-03-01 13:00", 60, 44.4759, -73.2121, "2"), // different day, doesn't count
+	}
+
+	// 2 doesn't have the skill, so 1 is the only one left
+	suggestions := SuggestCrew (job, testMembers(), schedule)
+	assert.Equal (t, 1, len(suggestions))
+
+	best := suggestions[0]
+	assert.Equal (t, "1", best.Member.Id)
+	assert.Equal (t, true, best.SkillMatch)
+	assert.Equal (t, true, best.AreaMatch)
+	assert.Equal (t, 1, best.JobsThatDay)
+	assert.InDelta (t, 5.3, best.Distance, 0.2)
+
+	// without a job type anyone can take it, and 2 has the lighter day
+	job.JobType = ""
+	suggestions = SuggestCrew (job, testMembers(), schedule)
+	assert.Equal (t, 2, len(suggestions))
+	assert.Equal (t, "2", suggestions[0].Member.Id)
+	assert.Equal (t, false, suggestions[0].SkillMatch)
+	assert.Equal (t, 0, suggestions[0].JobsThatDay)
+	assert.Equal (t, -1.0, suggestions[0].Distance)
+	assert.Contains (t, suggestions[0].Reasons, "no job type to match")
+	assert.Equal (t, "1", suggestions[1].Member.Id)
+}
+
+func TestDistanceMiles (t *testing.T) {
+	burlington := Point { Lat: 44.4759, Lng: -73.2121 }
+	montpelier := Point { Lat: 44.2601, Lng: -72.5754 }
+
+	assert.InDelta (t, 35.0, DistanceMiles (burlington, montpelier), 1.0)
+	assert.Equal (t, 0.0, DistanceMiles (burlington, burlington))
+	assert.Equal (t, false, Point{}.Valid())
+}
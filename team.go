@@ -11,6 +11,7 @@ import (
     "net/http"
     "context"
     "strings"
+    "encoding/json"
     
 )
 
@@ -26,6 +27,19 @@ type Member struct {
     Id, Name, Role, Email string 
     Active, FieldTech bool 
     ServiceAreas, Skills []string 
+    Extra map[string]json.RawMessage `json:"-"` // anything workiz sent that we don't have a field for
+    drift []Drift
+}
+
+// team members get the same treatment as jobs for unknown fields
+func (this *Member) UnmarshalJSON (b []byte) error {
+    type alias Member // so we don't end up back in here
+    return decodeModel (b, (*alias)(this), "Member", &this.Extra, &this.drift)
+}
+
+// what didn't line up the last time this was decoded
+func (this *Member) SchemaDrift () []Drift {
+    return this.drift
 }
 
 type Members []*Member 
@@ -52,15 +66,59 @@ func (this Members) FindName (id string) string {
     return "" // didn't find them
 }
 
+// returns the member with this id, nil if they're not in the list
+func (this Members) ById (id string) *Member {
+    for _, m := range this {
+        if strings.EqualFold (m.Id, id) { return m }
+    }
+    return nil
+}
+
+// returns the member with this name, nil if they're not in the list
+func (this Members) ByName (nm string) *Member {
+    for _, m := range this {
+        if strings.EqualFold (m.Name, nm) { return m }
+    }
+    return nil
+}
+
+// which team members ListTeamWithOptions returns
+// the zero value gives the active field techs, same as ListTeam
+type ListTeamOptions struct {
+    IncludeInactive bool
+    IncludeOffice bool // people who aren't field techs, like dispatchers
+    Roles, ServiceAreas, Skills []string // members need at least one of each of these, empty means anyone
+}
+
+func (this ListTeamOptions) match (m *Member) bool {
+    if this.IncludeInactive == false && m.Active == false { return false }
+    if this.IncludeOffice == false && m.FieldTech == false { return false }
+
+    if len(this.Roles) > 0 && containsFold (this.Roles, m.Role) == false { return false }
+    if len(this.ServiceAreas) > 0 && anyFold (this.ServiceAreas, m.ServiceAreas) == false { return false }
+    if len(this.Skills) > 0 && anyFold (this.Skills, m.Skills) == false { return false }
+
+    return true
+}
+
+// true if the member lists this skill
+func (this *Member) HasSkill (skill string) bool {
+    return containsFold (this.Skills, skill)
+}
+
+// true if the member works in this service area
+func (this *Member) Covers (serviceArea string) bool {
+    return containsFold (this.ServiceAreas, serviceArea)
+}
+
 type teamResponse struct {
     Data []*Member
 }
 
-// takes the jobs out of whatever this parent object is for
-func (this teamResponse) toMembers () (ret Members) {
+// takes the members out of whatever this parent object is for
+func (this teamResponse) toMembers (opts ListTeamOptions) (ret Members) {
     for _, m := range this.Data {
-        if m.Active == false { continue }
-        if m.FieldTech == false { continue }
+        if opts.match (m) == false { continue }
 
         // they're good to get jobs
         ret.Push(m)
@@ -72,16 +130,41 @@ func (this teamResponse) toMembers () (ret Members) {
  //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
 //-----------------------------------------------------------------------------------------------------------------------//
 
+// true if the value is in the list, ignoring case and spaces on the ends
+func containsFold (list []string, val string) bool {
+    val = strings.TrimSpace (val)
+    for _, l := range list {
+        if strings.EqualFold (strings.TrimSpace (l), val) { return true }
+    }
+    return false
+}
+
+// true if anything in want is in have
+func anyFold (want, have []string) bool {
+    for _, w := range want {
+        if containsFold (have, w) { return true }
+    }
+    return false
+}
+
   //-----------------------------------------------------------------------------------------------------------------------//
  //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
 //-----------------------------------------------------------------------------------------------------------------------//
 
-// returns all jobs that match our conditions
+// returns the active field techs, the people who can get jobs
 func (this *Workiz) ListTeam (ctx context.Context, token string) (Members, error) {
+    return this.ListTeamWithOptions (ctx, token, ListTeamOptions{})
+}
+
+// returns the team members that match the options
+// use this to get dispatchers and office staff, or to find inactive techs that are still on jobs
+func (this *Workiz) ListTeamWithOptions (ctx context.Context, token string, opts ListTeamOptions) (Members, error) {
     var resp teamResponse
     
     err := this.send (ctx, 0, http.MethodGet, token, "team/all/", nil, &resp)
     if err != nil { return nil, err } // bail
+
+    reportDrift (this, resp.Data...)
     
-    return resp.toMembers(), nil // we're good
+    return resp.toMembers (opts), nil // we're good
 }
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"context"
 	"time"
+	"encoding/json"
 )
 
 func TestTeam (t *testing.T) {
@@ -31,3 +32,27 @@ func TestTeam (t *testing.T) {
 	*/
 }
 
+
+func TestTeamOptions (t *testing.T) {
+	resp := teamResponse{}
+	err := json.Unmarshal ([]byte(`{"Data":[
+		{"Id":"1","Name":"Nathan Thomas","Role":"tech","Active":true,"FieldTech":true,"ServiceAreas":["Burlington"],"Skills":["Growler Fill"]},
+		{"Id":"2","Name":"Brooklyn Thomas","Role":"dispatcher","Active":true,"FieldTech":false},
+		{"Id":"3","Name":"Alissa Thomas","Role":"tech","Active":false,"FieldTech":true,"ServiceAreas":["Shelburne"],"Skills":["Full Case"]},
+		{"Id":"4","Name":"Gone Tech","Role":"tech","Active":true,"FieldTech":true,"ServiceAreas":["Shelburne"],"Skills":["full case "]}]}`), &resp)
+	if err != nil { t.Fatal(err) }
+
+	// the default is still just active field techs
+	members := resp.toMembers (ListTeamOptions{})
+	assert.Equal (t, 2, len(members))
+	assert.Equal (t, "1", members[0].Id)
+
+	members = resp.toMembers (ListTeamOptions { IncludeOffice: true, Roles: []string{"Dispatcher"} })
+	assert.Equal (t, 1, len(members))
+	assert.Equal (t, "2", members[0].Id)
+
+	members = resp.toMembers (ListTeamOptions { IncludeInactive: true, Skills: []string{"Full Case"}, ServiceAreas: []string{"shelburne"} })
+	assert.Equal (t, 2, len(members))
+	assert.Equal (t, "3", members[0].Id)
+	assert.Equal (t, true, members[1].HasSkill ("Full Case"))
+}
@@ -0,0 +1,240 @@
+/** ****************************************************************************************************************** **
+	Cached team roster
+	Keeps the team around so every crew update doesn't need its own ListTeam call
+	and lets the caller know when someone is added, renamed or deactivated in workiz
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "github.com/pkg/errors"
+
+    "context"
+    "sync"
+    "time"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type TeamEventKind string
+
+const (
+    TeamEvent_added         = TeamEventKind("added")
+    TeamEvent_renamed       = TeamEventKind("renamed")
+    TeamEvent_deactivated   = TeamEventKind("deactivated")
+    TeamEvent_reactivated   = TeamEventKind("reactivated")
+    TeamEvent_removed       = TeamEventKind("removed") // not in the list from workiz at all anymore
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// something that changed about a member between refreshes
+type TeamEvent struct {
+    Kind TeamEventKind
+    Member *Member // as they are now, or as they were for removed
+    OldName string // only for renamed
+}
+
+// the team roster for one account, refreshed every TTL or when Refresh is called
+// safe to share between goroutines
+type TeamCache struct {
+    TTL time.Duration
+    OnEvent func (TeamEvent) // called after a refresh for each change, once the refresh is done, so it can use the cache or call Refresh
+
+    w *Workiz
+    token string
+    list func (context.Context) (Members, error) // where the roster comes from, workiz unless a test swaps it
+
+    lock sync.RWMutex
+    everyone Members // including inactive and office staff, so we can tell when someone is deactivated
+    techs Members // active field techs, what ListTeam would give you
+    byId map[string]*Member
+    index *NameIndex
+    fetched time.Time
+
+    refreshing sync.Mutex // one refresh at a time
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// what changed between 2 rosters
+func diffTeam (old map[string]*Member, now Members) (ret []TeamEvent) {
+    seen := make(map[string]bool)
+    for _, m := range now {
+        seen[m.Id] = true
+
+        prev, ok := old[m.Id]
+        if ok == false {
+            ret = append (ret, TeamEvent { Kind: TeamEvent_added, Member: m })
+            continue
+        }
+
+        if prev.Name != m.Name {
+            ret = append (ret, TeamEvent { Kind: TeamEvent_renamed, Member: m, OldName: prev.Name })
+        }
+
+        switch {
+        case prev.Active && m.Active == false:
+            ret = append (ret, TeamEvent { Kind: TeamEvent_deactivated, Member: m })
+        case prev.Active == false && m.Active:
+            ret = append (ret, TeamEvent { Kind: TeamEvent_reactivated, Member: m })
+        }
+    }
+
+    for id, prev := range old {
+        if seen[id] == false {
+            ret = append (ret, TeamEvent { Kind: TeamEvent_removed, Member: prev })
+        }
+    }
+    return
+}
+
+// swaps in a new roster and returns what changed
+func (this *TeamCache) load (everyone Members, now time.Time) []TeamEvent {
+    byId := make(map[string]*Member, len(everyone))
+    for _, m := range everyone { byId[m.Id] = m }
+
+    resp := teamResponse { Data: everyone }
+
+    this.lock.Lock()
+    defer this.lock.Unlock()
+
+    var events []TeamEvent
+    if this.byId != nil { events = diffTeam (this.byId, everyone) } // the first load isn't a change
+
+    this.everyone = everyone
+    this.techs = resp.toMembers (ListTeamOptions{})
+    this.byId = byId
+    this.index = this.techs.Index()
+    this.fetched = now
+
+    return events
+}
+
+// true if we've never loaded, or the ttl is up
+func (this *TeamCache) stale () bool {
+    this.lock.RLock()
+    defer this.lock.RUnlock()
+    return this.fetched.IsZero() || (this.TTL > 0 && time.Since (this.fetched) > this.TTL)
+}
+
+// pulls the roster and returns what changed, the caller needs to hold refreshing
+func (this *TeamCache) reload (ctx context.Context) ([]TeamEvent, error) {
+    everyone, err := this.list (ctx)
+    if err != nil { return nil, err }
+
+    return this.load (everyone, time.Now()), nil
+}
+
+// tells OnEvent what changed, only after refreshing is let go
+// otherwise a callback that calls Refresh, or anything that finds the cache stale, would wait on itself forever
+func (this *TeamCache) notify (events []TeamEvent) {
+    if this.OnEvent == nil { return }
+    for _, e := range events { this.OnEvent (e) }
+}
+
+// refreshes if it's stale
+// everyone who finds it stale lines up on refreshing, so we check again once it's our turn
+// otherwise they'd each make their own call after the first one already took care of it
+func (this *TeamCache) fresh (ctx context.Context) error {
+    if this.stale() == false { return nil }
+
+    this.refreshing.Lock()
+    if this.stale() == false {
+        this.refreshing.Unlock()
+        return nil
+    }
+
+    events, err := this.reload (ctx)
+    this.refreshing.Unlock()
+    if err != nil { return err }
+
+    this.notify (events)
+    return nil
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// a ttl of 0 means it only loads once, and after that only when Refresh is called
+func NewTeamCache (w *Workiz, token string, ttl time.Duration) *TeamCache {
+    ret := &TeamCache { w: w, token: token, TTL: ttl }
+    ret.list = func (ctx context.Context) (Members, error) {
+        return w.ListTeamWithOptions (ctx, token, ListTeamOptions { IncludeInactive: true, IncludeOffice: true })
+    }
+    return ret
+}
+
+// pulls the team from workiz now, no matter how old the cache is
+func (this *TeamCache) Refresh (ctx context.Context) error {
+    this.refreshing.Lock()
+    events, err := this.reload (ctx)
+    this.refreshing.Unlock()
+    if err != nil { return err }
+
+    this.notify (events)
+    return nil
+}
+
+// the active field techs
+func (this *TeamCache) Members (ctx context.Context) (Members, error) {
+    if err := this.fresh (ctx); err != nil { return nil, err }
+
+    this.lock.RLock()
+    defer this.lock.RUnlock()
+    return this.techs, nil
+}
+
+// everyone, including inactive members and office staff
+func (this *TeamCache) All (ctx context.Context) (Members, error) {
+    if err := this.fresh (ctx); err != nil { return nil, err }
+
+    this.lock.RLock()
+    defer this.lock.RUnlock()
+    return this.everyone, nil
+}
+
+// finds anyone on the team by id, inactive or not
+func (this *TeamCache) ById (ctx context.Context, id string) (*Member, error) {
+    if err := this.fresh (ctx); err != nil { return nil, err }
+
+    this.lock.RLock()
+    defer this.lock.RUnlock()
+
+    m, ok := this.byId[id]
+    if ok == false { return nil, errors.Wrap (ErrNotFound, id) }
+    return m, nil
+}
+
+// looks up an active field tech by name
+func (this *TeamCache) ByName (ctx context.Context, name string) (*NameMatch, error) {
+    if err := this.fresh (ctx); err != nil { return nil, err }
+
+    this.lock.RLock()
+    defer this.lock.RUnlock()
+    return this.index.Lookup (name), nil
+}
+
+// UpdateJobCrew using the cached roster, so the names are the current ones
+func (this *TeamCache) UpdateJobCrew (ctx context.Context, secret, jobId string, fullNames []string) error {
+    team, err := this.Members (ctx)
+    if err != nil { return err }
+
+    return this.w.UpdateJobCrew (ctx, this.token, secret, jobId, team, fullNames)
+}
+
+// UpdateLeadCrew using the cached roster
+func (this *TeamCache) UpdateLeadCrew (ctx context.Context, secret, leadId string, fullNames []string) error {
+    team, err := this.Members (ctx)
+    if err != nil { return err }
+
+    return this.w.UpdateLeadCrew (ctx, this.token, secret, leadId, team, fullNames)
+}
@@ -0,0 +1,116 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"testing"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func TestTeamCacheEvents (t *testing.T) {
+	cache := NewTeamCache (&Workiz{}, "token", time.Hour)
+
+	active := true
+	first := Members {
+		&Member { Id: "1", Name: "Nate Thomas", Active: active, FieldTech: active },
+		&Member { Id: "2", Name: "Brooklyn Thomas", Active: active, FieldTech: active },
+		&Member { Id: "3", Name: "Alissa Thomas", Active: active, FieldTech: active },
+	}
+	assert.Equal (t, 0, len(cache.load (first, time.Now())), "the first load isn't a change")
+
+	second := Members {
+		&Member { Id: "1", Name: "Nathan Thomas", Active: active, FieldTech: active },
+		&Member { Id: "2", Name: "Brooklyn Thomas", FieldTech: active },
+		&Member { Id: "4", Name: "New Tech", Active: active, FieldTech: active },
+	}
+	events := cache.load (second, time.Now())
+
+	kinds := make(map[TeamEventKind]TeamEvent)
+	for _, e := range events { kinds[e.Kind] = e }
+	assert.Equal (t, 4, len(events))
+	assert.Equal (t, "Nate Thomas", kinds[TeamEvent_renamed].OldName)
+	assert.Equal (t, "2", kinds[TeamEvent_deactivated].Member.Id)
+	assert.Equal (t, "4", kinds[TeamEvent_added].Member.Id)
+	assert.Equal (t, "3", kinds[TeamEvent_removed].Member.Id)
+
+	// it's fresh, so none of these should try to hit workiz
+	ctx := context.Background()
+	techs, err := cache.Members (ctx)
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, 2, len(techs))
+
+	all, err := cache.All (ctx)
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, 3, len(all))
+
+	m, err := cache.ById (ctx, "2")
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, "Brooklyn Thomas", m.Name)
+
+	match, err := cache.ByName (ctx, "Nate Thomas")
+	if err != nil { t.Fatal(err) }
+	assert.Equal (t, "1", match.Member.Id)
+}
+
+func TestTeamCacheStampede (t *testing.T) {
+	cache := NewTeamCache (&Workiz{}, "token", time.Hour)
+
+	var calls int32
+	cache.list = func (ctx context.Context) (Members, error) {
+		atomic.AddInt32 (&calls, 1)
+		time.Sleep (20 * time.Millisecond) // long enough that everyone else finds it stale and waits
+		return Members { &Member { Id: "1", Name: "Nathan Thomas", Active: true, FieldTech: true } }, nil
+	}
+
+	// everyone shows up to an empty cache at the same time, only one of them should go to workiz
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add (1)
+		go func () {
+			defer wg.Done()
+			techs, err := cache.Members (context.Background())
+			assert.NoError (t, err)
+			assert.Equal (t, 1, len(techs))
+		}()
+	}
+	wg.Wait()
+	assert.Equal (t, int32(1), atomic.LoadInt32 (&calls))
+
+	// Refresh still goes to workiz no matter what
+	assert.NoError (t, cache.Refresh (context.Background()))
+	assert.Equal (t, int32(2), atomic.LoadInt32 (&calls))
+}
+
+func TestTeamCacheEventRefresh (t *testing.T) {
+	cache := NewTeamCache (&Workiz{}, "token", time.Hour)
+
+	name := "Nate Thomas"
+	cache.list = func (ctx context.Context) (Members, error) {
+		return Members { &Member { Id: "1", Name: name, Active: true, FieldTech: true } }, nil
+	}
+	assert.NoError (t, cache.Refresh (context.Background()))
+
+	// the callback refreshes again, which used to wait on the refresh that called it
+	refreshed := 0
+	cache.OnEvent = func (e TeamEvent) {
+		refreshed++
+		assert.NoError (t, cache.Refresh (context.Background()))
+	}
+
+	done := make(chan error)
+	go func () {
+		name = "Nathan Thomas"
+		done <- cache.Refresh (context.Background())
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError (t, err)
+		assert.Equal (t, 1, refreshed) // the second refresh didn't find anything new
+	case <-time.After (time.Second):
+		t.Fatal ("OnEvent calling Refresh deadlocked")
+	}
+}
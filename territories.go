@@ -0,0 +1,269 @@
+/** ****************************************************************************************************************** **
+	Territories
+	Groups jobs into compact areas, one per tech, using k-means on the coordinates
+	with a cap on how much work each territory can take so they come out roughly even
+
+** ****************************************************************************************************************** **/
+
+package workiz
+
+import (
+    "math"
+    "sort"
+    "time"
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- CONSTS ----------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type TerritoryBalance string
+
+const (
+    TerritoryBalance_none       = TerritoryBalance("")          // just the closest center, territories can be any size
+    TerritoryBalance_count      = TerritoryBalance("count")     // about the same number of jobs in each
+    TerritoryBalance_duration   = TerritoryBalance("duration")  // about the same amount of time in each
+)
+
+const (
+    defaultTerritoryIterations  = 50
+    defaultTerritorySlack       = 0.1 // territories can be 10% over even before we stop adding to them
+    territorySeedMiles          = 0.1 // seeds closer than this are the same place as far as we're concerned
+)
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- STRUCTS ---------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+type TerritoryOptions struct {
+    Count int // how many territories, at most one per job with coordinates. ignored when Members is set
+    Members Members // one territory per member, started from the jobs in their ServiceAreas. with more members than jobs, some are left empty
+    Balance TerritoryBalance
+    Slack float64 // how far over an even share a territory can go, 0.1 means 10%. 0 uses 10%
+    MaxIterations int // 0 uses 50
+}
+
+type Territory struct {
+    Member *Member // set when the territories were made from members
+    Center Point
+    Jobs []*Job
+    Duration time.Duration // all the jobs together
+    Radius float64 // miles from the center to the farthest job
+}
+
+type TerritoryPlan struct {
+    Territories []*Territory
+    Skipped []*Job // jobs without coordinates
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- PRIVATE FUNCTIONS -----------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+func jobDuration (j *Job) time.Duration {
+    start, end := j.Window()
+    return end.Sub (start)
+}
+
+// the average of the points, fine for anything the size of a service area
+func centroid (pts []Point) Point {
+    var ret Point
+    for _, pt := range pts {
+        ret.Lat += pt.Lat
+        ret.Lng += pt.Lng
+    }
+    ret.Lat /= float64(len(pts))
+    ret.Lng /= float64(len(pts))
+    return ret
+}
+
+// starting centers, from the members' service areas when we have them
+// anything left over is spread out by taking the job farthest from every center we have so far
+// members sharing an area would start in the same place and one of them would never get anything
+// so only the first one keeps that seed, the rest get spread out too
+func seedTerritories (pts []Point, jobs []*Job, members Members, k int) []Point {
+    centers := make([]Point, k)
+    set := make([]bool, k)
+
+    for i, m := range members {
+        var mine []Point
+        for j, job := range jobs {
+            if len(job.ServiceArea) > 0 && m.Covers (job.ServiceArea) { mine = append (mine, pts[j]) }
+        }
+        if len(mine) == 0 { continue }
+
+        center, taken := centroid (mine), false
+        for c := 0; c < i; c++ {
+            if set[c] && DistanceMiles (centers[c], center) < territorySeedMiles { taken = true }
+        }
+        if taken == false { centers[i], set[i] = center, true }
+    }
+
+    seeded := false
+    for _, s := range set { seeded = seeded || s }
+
+    middle := centroid (pts)
+    for i := range centers {
+        if set[i] { continue }
+
+        best, bestScore := 0, math.Inf (-1)
+        for j, pt := range pts {
+            score := -DistanceMiles (middle, pt) // nothing to go on yet, so start near the middle of everything
+            if seeded {
+                score = math.Inf (1)
+                for c := range centers {
+                    if set[c] { score = math.Min (score, DistanceMiles (centers[c], pt)) }
+                }
+            }
+            if score > bestScore { best, bestScore = j, score }
+        }
+        centers[i], set[i], seeded = pts[best], true, true
+    }
+    return centers
+}
+
+// puts each job in a territory, closest center first
+// when balancing, the jobs with the most to lose from not getting their closest go first
+// and a territory stops taking jobs once it's full
+func assignTerritories (pts []Point, weights []float64, centers []Point, capacity float64) []int {
+    ret := make([]int, len(pts))
+
+    type choice struct {
+        job int
+        order []int // centers, closest first
+        dist []float64
+    }
+    choices := make([]*choice, len(pts))
+    for j, pt := range pts {
+        c := &choice { job: j, order: make([]int, len(centers)), dist: make([]float64, len(centers)) }
+        for i, center := range centers {
+            c.order[i], c.dist[i] = i, DistanceMiles (center, pt)
+        }
+        sort.SliceStable (c.order, func (a, b int) bool { return c.dist[c.order[a]] < c.dist[c.order[b]] })
+        choices[j] = c
+    }
+
+    if capacity <= 0 {
+        for j, c := range choices { ret[j] = c.order[0] }
+        return ret
+    }
+
+    // regret, how much further the second choice is than the first
+    regret := func (c *choice) float64 {
+        if len(c.order) < 2 { return 0 }
+        return c.dist[c.order[1]] - c.dist[c.order[0]]
+    }
+    sort.SliceStable (choices, func (a, b int) bool { return regret (choices[a]) > regret (choices[b]) })
+
+    load := make([]float64, len(centers))
+    for _, c := range choices {
+        picked := -1
+        for _, i := range c.order {
+            if load[i] + weights[c.job] <= capacity {
+                picked = i
+                break
+            }
+        }
+
+        if picked < 0 {
+            // everything's full, so it goes to whoever has the least
+            picked = 0
+            for i := range load {
+                if load[i] < load[picked] { picked = i }
+            }
+        }
+
+        ret[c.job] = picked
+        load[picked] += weights[c.job]
+    }
+    return ret
+}
+
+  //-----------------------------------------------------------------------------------------------------------------------//
+ //----- FUNCTIONS -------------------------------------------------------------------------------------------------------//
+//-----------------------------------------------------------------------------------------------------------------------//
+
+// groups the jobs into territories, running k-means until nothing moves
+// with Members set there's one territory per member, in the same order, so they can each take one
+func Territories (jobs []*Job, opts TerritoryOptions) *TerritoryPlan {
+    plan := &TerritoryPlan{}
+
+    var located []*Job
+    var pts []Point
+    for _, j := range jobs {
+        pt, ok := j.Location()
+        if ok == false {
+            plan.Skipped = append (plan.Skipped, j)
+            continue
+        }
+        located = append (located, j)
+        pts = append (pts, pt)
+    }
+
+    // more territories than jobs would just leave some empty, unless they're for members and the caller needs one each
+    k := opts.Count
+    if k > len(located) { k = len(located) }
+    if len(opts.Members) > 0 { k = len(opts.Members) }
+    if k <= 0 { return plan }
+
+    for i := 0; i < k; i++ {
+        t := &Territory{}
+        if len(opts.Members) > 0 { t.Member = opts.Members[i] }
+        plan.Territories = append (plan.Territories, t)
+    }
+    if len(located) == 0 { return plan }
+
+    // what we're balancing on
+    weights := make([]float64, len(located))
+    total, heaviest := 0.0, 0.0
+    for j, job := range located {
+        switch opts.Balance {
+        case TerritoryBalance_duration:
+            weights[j] = jobDuration (job).Minutes()
+        default:
+            weights[j] = 1
+        }
+        total += weights[j]
+        heaviest = math.Max (heaviest, weights[j])
+    }
+
+    capacity := 0.0
+    if opts.Balance != TerritoryBalance_none {
+        slack := opts.Slack
+        if slack <= 0 { slack = defaultTerritorySlack }
+        capacity = math.Max (heaviest, total / float64(k) * (1 + slack))
+    }
+
+    iterations := opts.MaxIterations
+    if iterations <= 0 { iterations = defaultTerritoryIterations }
+
+    centers := seedTerritories (pts, located, opts.Members, k)
+
+    var assigned []int
+    for it := 0; it < iterations; it++ {
+        next := assignTerritories (pts, weights, centers, capacity)
+
+        same := assigned != nil
+        for j := range next {
+            if same && next[j] != assigned[j] { same = false }
+        }
+        assigned = next
+        if same { break }
+
+        // move each center to the middle of its jobs, empty ones stay where they are
+        groups := make([][]Point, k)
+        for j, i := range assigned { groups[i] = append (groups[i], pts[j]) }
+        for i, g := range groups {
+            if len(g) > 0 { centers[i] = centroid (g) }
+        }
+    }
+
+    for i, t := range plan.Territories { t.Center = centers[i] }
+    for j, i := range assigned {
+        t := plan.Territories[i]
+        t.Jobs = append (t.Jobs, located[j])
+        t.Duration += jobDuration (located[j])
+        t.Radius = math.Max (t.Radius, DistanceMiles (t.Center, pts[j]))
+    }
+    return plan
+}
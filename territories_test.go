@@ -0,0 +1,82 @@
+package workiz
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"testing"
+)
+
+func TestTerritories (t *testing.T) {
+	// 2 clumps, 4 jobs around burlington and 2 around montpelier
+	jobs := []*Job {
+		testJob (t, "AAA111", "", 0, 44.47, -73.21),
+		testJob (t, "AAA222", "", 0, 44.48, -73.20),
+		testJob (t, "AAA333", "", 0, 44.49, -73.19),
+		testJob (t, "AAA444", "", 0, 44.46, -73.22),
+		testJob (t, "BBB111", "", 0, 44.26, -72.57),
+		testJob (t, "BBB222", "", 0, 44.27, -72.58),
+		testJob (t, "CCC333", "", 0, 0, 0),
+	}
+	for _, j := range jobs[4:6] { j.ServiceArea = "Montpelier" }
+
+	ids := func (tr *Territory) (ret []string) {
+		for _, j := range tr.Jobs { ret = append (ret, j.UUID) }
+		return
+	}
+
+	// left alone it finds the 2 clumps
+	plan := Territories (jobs, TerritoryOptions { Count: 2 })
+	if assert.Equal (t, 1, len(plan.Skipped)) {
+		assert.Equal (t, "CCC333", plan.Skipped[0].UUID)
+	}
+	if assert.Equal (t, 2, len(plan.Territories)) {
+		sizes := []int{ len(plan.Territories[0].Jobs), len(plan.Territories[1].Jobs) }
+		assert.ElementsMatch (t, []int{ 4, 2 }, sizes)
+		for _, tr := range plan.Territories {
+			assert.Equal (t, true, tr.Radius < 5) // nothing from the other clump
+		}
+	}
+
+	// balanced on count they each get 3, so one of the burlington jobs has to make the trip
+	plan = Territories (jobs, TerritoryOptions { Count: 2, Balance: TerritoryBalance_count, Slack: 0.01 })
+	if assert.Equal (t, 2, len(plan.Territories)) {
+		assert.Equal (t, 3, len(plan.Territories[0].Jobs))
+		assert.Equal (t, 3, len(plan.Territories[1].Jobs))
+	}
+
+	// seeded from service areas, the member covering montpelier gets those jobs
+	yes := true
+	members := Members {
+		&Member { Id: "1", Name: "Nathan Thomas", Active: yes, FieldTech: yes, ServiceAreas: []string{ "Burlington" } },
+		&Member { Id: "2", Name: "Brooklyn Thomas", Active: yes, FieldTech: yes, ServiceAreas: []string{ "Montpelier" } },
+	}
+	for _, j := range jobs[:4] { j.ServiceArea = "Burlington" }
+
+	plan = Territories (jobs, TerritoryOptions { Members: members })
+	if assert.Equal (t, 2, len(plan.Territories)) {
+		assert.Equal (t, "1", plan.Territories[0].Member.Id)
+		assert.Equal (t, []string{ "AAA111", "AAA222", "AAA333", "AAA444" }, ids (plan.Territories[0]))
+		assert.Equal (t, "2", plan.Territories[1].Member.Id)
+		assert.Equal (t, []string{ "BBB111", "BBB222" }, ids (plan.Territories[1]))
+		assert.Equal (t, 2 * defaultJobDuration, plan.Territories[1].Duration)
+	}
+
+	// both members cover burlington, they'd start in the same place, so one gets spread out to montpelier
+	members[1].ServiceAreas = []string{ "Burlington" }
+	plan = Territories (jobs, TerritoryOptions { Members: members })
+	if assert.Equal (t, 2, len(plan.Territories)) {
+		assert.Equal (t, 4, len(plan.Territories[0].Jobs))
+		assert.Equal (t, []string{ "BBB111", "BBB222" }, ids (plan.Territories[1]))
+	}
+
+	// never more territories than jobs to put in them
+	plan = Territories (jobs[4:6], TerritoryOptions { Count: 3 })
+	if assert.Equal (t, 2, len(plan.Territories)) {
+		assert.Equal (t, 1, len(plan.Territories[0].Jobs))
+		assert.Equal (t, 1, len(plan.Territories[1].Jobs))
+	}
+
+	// nothing to group
+	assert.Equal (t, 0, len(Territories (jobs, TerritoryOptions{}).Territories))
+	assert.Equal (t, 0, len(Territories (nil, TerritoryOptions { Count: 3 }).Territories))
+}
@@ -14,13 +14,14 @@ package workiz
 import (
     "github.com/pkg/errors"
 
-    // "fmt"
+    "fmt"
     "context"
     "strings"
     "time"
     "net/http"
     "encoding/json"
     "os"
+    "sync"
 )
 
   //-----------------------------------------------------------------------------------------------------------------------//
@@ -35,6 +36,7 @@ var (
 	ErrTooManyRecords	= errors.New("Too many records returned")
     ErrAuthExpired      = errors.New("Auth Expired")
     ErrQuota            = errors.New("Too many requests - quota limit")
+    ErrPartialDecode    = errors.New("Some records couldn't be decoded")
 )
 
 type assignCrew func (context.Context, string, string, string, string) error 
@@ -59,7 +61,8 @@ type baseResp struct {
     Flag, Error bool 
     Msg string 
     Data []struct {
-        UUID, Client_id string 
+        UUID string 
+        Client_id FlexString
     }
     Code int 
 }
@@ -120,27 +123,10 @@ func (this *workizTime) UnmarshalJSON (b []byte) (err error) {
     return
 }
 
-type workizComment []string
-
-func (this *workizComment) UnmarshalJSON (b []byte) error {
-
-    // see if it's an empty string, if so, we're done
-    if string(b) == `""` { return nil }
-
-    // we have comments
-    var data []struct {
-        Comment string 
-    }
-
-    err := json.Unmarshal (b, &data)
-    if err != nil { return err } // this is bad
-
-    // this worked, so populate our comments
-    for _, c := range data {
-        *this = append (*this, c.Comment)
-    }
-
-    return nil 
+// goes back out the same way it came in
+func (this workizTime) MarshalJSON () ([]byte, error) {
+    if this.IsZero() { return []byte("null"), nil }
+    return []byte(`"` + this.Format("2006-01-02 15:04:05") + `"`), nil
 }
 
 type teamGeneric struct {
@@ -163,12 +149,73 @@ func (this *Error) Err () error {
 	return errors.Wrapf (ErrUnexpected, "Workiz Error : %d : %s", this.StatusCode, this.Msg)
 }
 
+// a single record from a list that we couldn't decode
+// the rest of the list still comes back from the Partial calls, this just tells you what was left out
+type DecodeError struct {
+    Index int // position in everything workiz sent for the call, counting across pages
+    UUID string // if we could at least get that much out of it
+    Err error
+    Raw json.RawMessage
+}
+
+func (this *DecodeError) Error () string {
+    return fmt.Sprintf ("record %d (%s) : %s", this.Index, this.UUID, this.Err.Error())
+}
+
+// all the records we had to skip
+// errors.Cause() on this is ErrPartialDecode, use errors.As to get at the details
+type DecodeErrors []*DecodeError
+
+func (this DecodeErrors) Error () string {
+    msgs := make([]string, 0, len(this))
+    for _, e := range this { msgs = append (msgs, e.Error()) }
+    return fmt.Sprintf ("%s : %d : %s", ErrPartialDecode.Error(), len(this), strings.Join (msgs, " | "))
+}
+
+func (this DecodeErrors) Cause () error { return ErrPartialDecode }
+func (this DecodeErrors) Unwrap () error { return ErrPartialDecode }
+
+// returns nil when there weren't any, so we don't hand back a typed nil
+func (this DecodeErrors) err () error {
+    if len(this) == 0 { return nil }
+    return this
+}
+
+// moves the page positions along by however many records came before this page
+func (this DecodeErrors) offset (by int) DecodeErrors {
+    for _, e := range this { e.Index += by }
+    return this
+}
+
   //-----------------------------------------------------------------------------------------------------------------------//
  //----- CLASS -----------------------------------------------------------------------------------------------------------//
 //-----------------------------------------------------------------------------------------------------------------------//
 
+// use it as a pointer, &Workiz{}, and don't copy it once it's in use
+// it holds the lock for the rate limit, so a copy would throttle on its own and send more than RateLimit between them
 type Workiz struct {
-	
+    Strict bool // when set, OnDrift gets called with any new or type changed fields in the responses
+    OnDrift func (Drift)
+    ReportMissing bool // strict mode also reports our fields workiz didn't send. it leaves out most empty fields, so this is noisy
+
+    RateLimit int // most requests per second we'll send, 0 means no limit
+
+    // when set, UpdateJobSchedule and UpdateJobCrew check the rest of that day first
+    // and return a Conflicts error instead of double booking someone
+    Preflight *ConflictOptions
+
+    // when set, CreateJob and CreateLead make sure the address can be found first
+    // and return ErrInvalidAddress instead of creating something we can't route to
+    // the job and lead lists also use it to fill in any missing coordinates
+    Geocoder Geocoder
+
+    // when set, CreateJob and CreateLead fill in the ServiceArea from the address
+    // a ServiceArea the caller already set is left alone
+    // PolygonAreas need the Geocoder set too, they can't place an address without coordinates
+    ServiceAreas AreaResolver
+
+    lock sync.Mutex
+    nextSend time.Time // when the next request is allowed to go out
 }
 
   //-----------------------------------------------------------------------------------------------------------------------//
@@ -188,6 +235,25 @@ func parseConfig (jsonFile string) (*Config, error) {
     return ret, errors.WithStack(err)
 }
 
+// decodes each record in a page on its own, so one bad record doesn't take out the rest
+func decodeRecords[T any] (raw []json.RawMessage) (ret []*T, bad DecodeErrors) {
+    for i, r := range raw {
+        rec := new(T)
+        err := json.Unmarshal (r, rec)
+        if err == nil {
+            ret = append (ret, rec)
+            continue
+        }
+
+        // see if we can at least get the id so the caller knows which one it was
+        var id struct { UUID string }
+        json.Unmarshal (r, &id)
+
+        bad = append (bad, &DecodeError { Index: i, UUID: id.UUID, Err: errors.WithStack (err), Raw: r })
+    }
+    return
+}
+
 /* handles the high level logic of changing which crew members are assigned to a job or lead
 crew members need to be assigned one at a time
 and if you assign the same one twice, you get an error
@@ -197,47 +263,23 @@ this is brutal, the name of the crew member you get from the get job call may no
 so in order to remove them, and add them, we need to reference them by the id first, and then their current name
 my guess is they don't use a relational database, so if you change the crew member's name after assigning them to a job it stays
 as the old name in the job table/object
+
+any name that doesn't match exactly one team member comes back as ErrNotFound, before anything is changed
+if only one assign or unassign failed, its error comes back as is, so errors.Cause() still finds things like ErrAuthExpired
 */
 func (this *Workiz) handleCrew (ctx context.Context, existingTeam []*teamGeneric, token, secret, jobId string, team Members, fullNames []string, assFn assignCrew, unassFn unassignCrew) error {
-    // first step, add the missing ones
-    for _, name := range fullNames {
-        nameId := team.FindId (name) // find the id by the name
-        if len(nameId) == 0 { continue } // this is a bad name, no id, so nothing we can do at this point
-
-        exists := false 
-        for _, team := range existingTeam {
-            //if strings.EqualFold (team.Name, name) { 
-            if strings.EqualFold (team.Id, nameId) {
-                exists = true 
-                break 
-            }
-        }
+    plan := planCrew (existingTeam, team, fullNames) // works out the adds and removes by id, using the current names
+    plan.Id, plan.assign, plan.unassign = jobId, assFn, unassFn
 
-        if exists == false {
-            // it's missing so add it
-            err := assFn (ctx, token, secret, jobId, name)
-            if err != nil { return err }
-        }
+    // a name we can't place would otherwise just get dropped, and whoever it was meant to be may get removed
+    // so nothing changes until every name matches someone
+    if len(plan.Unknown) > 0 {
+        return errors.Wrapf (ErrNotFound, "%s : no single team member matches %s", jobId, strings.Join (plan.Unknown, ", "))
     }
 
-    // second step, remove the assigned crew that are no longer assigned
-    for _, existing := range existingTeam {
-        existingName := team.FindName (existing.Id) // get the actual current name using the assigned id
-        if len(existingName) == 0 { continue } // not sure this can happen, but we can't do anything if it does
-
-        exists := false 
-        for _, name := range fullNames {
-            if strings.EqualFold (existingName, name) {
-                exists = true 
-                break
-            }
-        }
+    _, err := this.ApplyCrewPlan (ctx, token, secret, plan, CrewOptions{})
 
-        if exists == false {
-            // they're currently assigned and we need to remove them
-            err := unassFn (ctx, token, secret, jobId, existingName)
-            if err != nil { return err }
-        }
-    }
-    return nil // we got everything figured out
+    var bad BatchErrors
+    if errors.As (err, &bad) && len(bad) == 1 { return errors.Wrap (bad[0].Err, jobId) }
+    return err
 }